@@ -0,0 +1,93 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command bbp-check compares hexadecimal digits of pi read from the bucket
+// with digits computed independently with the Bailey-Borwein-Plouffe formula
+// at random positions, checking both the data and the offset arithmetic of
+// the readers.
+//
+//	go run ./cmd/bbp-check -samples 100 -max 10000000
+package main
+
+import (
+	"context"
+	"flag"
+	"math/rand"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/objflags"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"go.uber.org/zap"
+)
+
+var logger *zap.SugaredLogger
+
+func main() {
+	l, _ := zap.NewDevelopment()
+	defer l.Sync()
+	zap.ReplaceGlobals(l)
+	logger = l.Sugar()
+
+	n := flag.Int("samples", 20, "Number of random positions to check")
+	max := flag.Int64("max", 10000000, "Positions are below this digit offset; BBP takes time linear in the position")
+	seed := flag.Int64("seed", 0, "Seed of the random positions (0 uses the current time)")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of positions computed in parallel")
+	src := objflags.Register(flag.CommandLine, index.BucketName)
+	src.RegisterCache(flag.CommandLine, 0)
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	set := index.Hexadecimal
+	rnd := rand.New(rand.NewSource(*seed))
+	var samples []*sample
+	for _, pos := range samplePositions(rnd, *n, *max, set.TotalDigits(), set.BlockSize()) {
+		samples = append(samples, &sample{Pos: pos})
+	}
+	logger.Infow("checking", "samples", len(samples), "max", *max, "seed", *seed)
+
+	ctx := context.Background()
+	client, err := src.NewClient(ctx)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	rrd := set.NewReader(ctx, client.Bucket(src.Bucket))
+	defer rrd.Close()
+	checkAll(samples, unpack.NewReader(ctx, rrd), *workers)
+
+	if err := writeReport(os.Stdout, samples); err != nil {
+		logger.Errorf("couldn't write the report: %v", err)
+		os.Exit(1)
+	}
+	counts := make(map[Status]int)
+	for _, s := range samples {
+		counts[s.Status]++
+	}
+	logger.Infow("check finished",
+		string(StatusOK), counts[StatusOK],
+		string(StatusMismatch), counts[StatusMismatch],
+		string(StatusAmbiguous), counts[StatusAmbiguous],
+		string(StatusError), counts[StatusError],
+	)
+	if counts[StatusMismatch] > 0 || counts[StatusError] > 0 {
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/bbp"
+)
+
+// Status is the result of the check of a sample.
+type Status string
+
+const (
+	StatusOK        Status = "ok"
+	StatusMismatch  Status = "mismatch"
+	StatusAmbiguous Status = "ambiguous"
+	StatusError     Status = "error"
+)
+
+// sample is a position at which the digits read are compared with BBP.
+type sample struct {
+	// Pos is the digit offset (0 is the first digit after the point).
+	Pos int64
+	// Want are the digits computed with BBP and Got the digits read.
+	Want, Got string
+
+	Status Status
+	Detail string
+}
+
+// samplePositions returns n random positions in [0, max) at which
+// bbp.NumDigits digits are available, plus positions straddling every block
+// boundary below max, sorted.
+func samplePositions(rnd *rand.Rand, n int, max, total, blockSize int64) []int64 {
+	if total < max {
+		max = total
+	}
+	max -= bbp.NumDigits
+	if max < 0 {
+		return nil
+	}
+	seen := make(map[int64]bool)
+	var res []int64
+	add := func(pos int64) {
+		if pos >= 0 && pos <= max && !seen[pos] {
+			seen[pos] = true
+			res = append(res, pos)
+		}
+	}
+	for i := 0; i < n; i++ {
+		add(rnd.Int63n(max + 1))
+	}
+	if blockSize > 0 {
+		for b := blockSize; b <= max; b += blockSize {
+			add(b - bbp.NumDigits/2)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// check compares the digits at s.Pos read from rd with BBP.
+func (s *sample) check(rd io.ReaderAt) {
+	want, err := bbp.Digits(s.Pos)
+	if errors.Is(err, bbp.ErrAmbiguous) {
+		s.Status = StatusAmbiguous
+		return
+	}
+	if err != nil {
+		s.Status, s.Detail = StatusError, err.Error()
+		return
+	}
+	s.Want = want
+	buf := make([]byte, bbp.NumDigits)
+	n, err := rd.ReadAt(buf, s.Pos)
+	s.Got = string(buf[:n])
+	if n < len(buf) {
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		s.Status, s.Detail = StatusError, err.Error()
+		return
+	}
+	if s.Got != s.Want {
+		s.Status = StatusMismatch
+		return
+	}
+	s.Status = StatusOK
+}
+
+func checkAll(samples []*sample, rd io.ReaderAt, workers int) {
+	ch := make(chan *sample)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range ch {
+				s.check(rd)
+				logger.Debugw("checked", "position", s.Pos, "status", s.Status)
+			}
+		}()
+	}
+	for _, s := range samples {
+		ch <- s
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// writeReport writes one "status, position, bbp digits, read digits" line per
+// sample.
+func writeReport(w io.Writer, samples []*sample) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range samples {
+		fmt.Fprintf(bw, "%s, %d, %s, %s", s.Status, s.Pos, s.Want, s.Got)
+		if s.Detail != "" {
+			fmt.Fprintf(bw, ", %s", s.Detail)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/bbp"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	logger = zap.NewNop().Sugar()
+}
+
+func TestSample_Positions(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	pos := samplePositions(rnd, 50, 1000, 250, 40)
+	assert.IsIncreasing(t, pos)
+	for _, p := range pos {
+		assert.GreaterOrEqual(t, p, int64(0))
+		assert.LessOrEqual(t, p, int64(250-bbp.NumDigits))
+	}
+	// The boundaries of the blocks at 40, 80, ... 200.
+	for b := int64(40); b <= 200; b += 40 {
+		assert.Contains(t, pos, b-bbp.NumDigits/2)
+	}
+	assert.Empty(t, samplePositions(rnd, 10, bbp.NumDigits-1, 250, 40))
+}
+
+func TestSample_Check(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// Blocks ending in the middle of a word.
+	f, err := tests.NewFixture(tests.PiHex, 16, 40)
+	require.NoError(t, err)
+	// Change the last digit of the first word of block 2, digit 95.
+	client := f.Client()
+	name := f.Set[2].Name
+	data := append([]byte(nil), f.Files[name]...)
+	data[f.Set[2].FirstDigitOffset] ^= 1
+	client.Put(tests.FixtureBucket, name, data)
+	rrd := f.Set.NewReader(ctx, client.Bucket(tests.FixtureBucket))
+	defer rrd.Close()
+
+	var samples []*sample
+	for pos := int64(0); pos <= int64(len(f.Digits)); pos++ {
+		samples = append(samples, &sample{Pos: pos})
+	}
+	checkAll(samples, unpack.NewReader(ctx, rrd), 4)
+	for _, s := range samples {
+		switch {
+		case s.Pos+bbp.NumDigits > int64(len(f.Digits)):
+			assert.Equal(t, StatusError, s.Status, "position %d", s.Pos)
+		case s.Pos <= 95 && 95 < s.Pos+bbp.NumDigits:
+			assert.Equal(t, StatusMismatch, s.Status, "position %d", s.Pos)
+		default:
+			assert.Equal(t, StatusOK, s.Status, "position %d", s.Pos)
+			assert.Equal(t, f.Digits[s.Pos:s.Pos+bbp.NumDigits], s.Got)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, samples[:1]))
+	assert.Equal(t, "ok, 0, 243f6a8885a308d3, 243f6a8885a308d3\n", buf.String())
+}
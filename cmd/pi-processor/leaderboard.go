@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/detect"
+)
+
+// better reports whether a ranks above b: longer first, then earlier.
+func better(a, b *detect.Match) bool {
+	if a.Length != b.Length {
+		return a.Length > b.Length
+	}
+	return a.Pos < b.Pos
+}
+
+type matchKey struct {
+	pos    int64
+	length int
+}
+
+// matchHeap is a min-heap with the lowest ranked match at the top.
+type matchHeap []*detect.Match
+
+func (h matchHeap) Len() int            { return len(h) }
+func (h matchHeap) Less(i, j int) bool  { return better(h[j], h[i]) }
+func (h matchHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x interface{}) { *h = append(*h, x.(*detect.Match)) }
+func (h *matchHeap) Pop() interface{} {
+	old := *h
+	m := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return m
+}
+
+// topK keeps the k best matches of a detector.
+type topK struct {
+	k    int
+	h    matchHeap
+	seen map[matchKey]bool
+}
+
+func newTopK(k int) *topK {
+	return &topK{k: k, seen: make(map[matchKey]bool)}
+}
+
+func (t *topK) add(m *detect.Match) {
+	if t.k <= 0 {
+		return
+	}
+	key := matchKey{m.Pos, m.Length}
+	// Detectors report each match once but be safe against duplicates.
+	if t.seen[key] {
+		return
+	}
+	if len(t.h) < t.k {
+		heap.Push(&t.h, m)
+		t.seen[key] = true
+		return
+	}
+	if !better(m, t.h[0]) {
+		return
+	}
+	delete(t.seen, matchKey{t.h[0].Pos, t.h[0].Length})
+	t.h[0] = m
+	heap.Fix(&t.h, 0)
+	t.seen[key] = true
+}
+
+// sorted returns the matches from the best to the worst.
+func (t *topK) sorted() []*detect.Match {
+	res := make([]*detect.Match, len(t.h))
+	copy(res, t.h)
+	sort.Slice(res, func(i, j int) bool { return better(res[i], res[j]) })
+	return res
+}
+
+// leaderboard keeps the top k matches per detector.
+// It is safe for concurrent use.
+type leaderboard struct {
+	lock   sync.Mutex
+	k      int
+	boards map[string]*topK
+}
+
+func newLeaderboard(k int) *leaderboard {
+	return &leaderboard{k: k, boards: make(map[string]*topK)}
+}
+
+func (l *leaderboard) add(m detect.Match) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.addLocked(&m)
+}
+
+func (l *leaderboard) addLocked(m *detect.Match) {
+	b, ok := l.boards[m.Detector]
+	if !ok {
+		b = newTopK(l.k)
+		l.boards[m.Detector] = b
+	}
+	b.add(m)
+}
+
+// merge adds all matches in o to l.
+func (l *leaderboard) merge(o *leaderboard) {
+	o.lock.Lock()
+	var all []*detect.Match
+	for _, b := range o.boards {
+		all = append(all, b.h...)
+	}
+	o.lock.Unlock()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for _, m := range all {
+		l.addLocked(m)
+	}
+}
+
+// reset drops all matches.
+func (l *leaderboard) reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.boards = make(map[string]*topK)
+}
+
+// write writes the leaderboard of every detector in name order.
+// Each line has the rank, length, position, digits and note of a match.
+func (l *leaderboard) write(w io.Writer) error {
+	l.lock.Lock()
+	names := make([]string, 0, len(l.boards))
+	sorted := make(map[string][]*detect.Match, len(l.boards))
+	for name, b := range l.boards {
+		names = append(names, name)
+		sorted[name] = b.sorted()
+	}
+	l.lock.Unlock()
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "# %s\n", name)
+		for i, m := range sorted[name] {
+			fmt.Fprintf(bw, "%d, %d, %d, %s", i+1, m.Length, m.Position(), m.Digits)
+			if m.Note != "" {
+				fmt.Fprintf(bw, ", %s", m.Note)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	return bw.Flush()
+}
+
+// persist atomically replaces the file at path with the leaderboard.
+func (l *leaderboard) persist(path string) error {
+	return writeAtomic(path, l.write)
+}
+
+// writeAtomic replaces the file at path with the output of write, so
+// readers never see a partially written file.
+func writeAtomic(path string, write func(io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/detect"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboard_TopK(t *testing.T) {
+	t.Parallel()
+	l := newLeaderboard(3)
+	l.add(detect.Match{Detector: "palindrome", Pos: 100, Length: 9, Digits: "123454321", IntegerDigits: 1})
+	l.add(detect.Match{Detector: "palindrome", Pos: 50, Length: 9, Digits: "987656789", IntegerDigits: 1})
+	l.add(detect.Match{Detector: "palindrome", Pos: 10, Length: 7, Digits: "1234321", IntegerDigits: 1})
+	l.add(detect.Match{Detector: "palindrome", Pos: 70, Length: 11, Digits: "12345654321", Note: "prime", IntegerDigits: 1})
+	l.add(detect.Match{Detector: "palindrome", Pos: 5, Length: 3, Digits: "121", IntegerDigits: 1})
+	// Duplicate from an overlapping chunk.
+	l.add(detect.Match{Detector: "palindrome", Pos: 70, Length: 11, Digits: "12345654321", Note: "prime", IntegerDigits: 1})
+	l.add(detect.Match{Detector: "run", Pos: 762, Length: 6, Digits: "999999", IntegerDigits: 1})
+
+	var buf bytes.Buffer
+	require.NoError(t, l.write(&buf))
+	assert.Equal(t,
+		"# palindrome\n"+
+			"1, 11, 71, 12345654321, prime\n"+
+			"2, 9, 51, 987656789\n"+
+			"3, 9, 101, 123454321\n"+
+			"# run\n"+
+			"1, 6, 763, 999999\n",
+		buf.String())
+}
+
+func TestLeaderboard_NoMatchesKept(t *testing.T) {
+	t.Parallel()
+	for _, k := range []int{0, -1} {
+		l := newLeaderboard(k)
+		l.add(detect.Match{Detector: "run", Pos: 762, Length: 6, Digits: "999999", IntegerDigits: 1})
+		l.add(detect.Match{Detector: "run", Pos: 1, Length: 7, Digits: "1111111", IntegerDigits: 1})
+		var buf bytes.Buffer
+		require.NoError(t, l.write(&buf), "k %d", k)
+		assert.NotContains(t, buf.String(), "999999", "k %d", k)
+	}
+}
+
+func TestLeaderboard_Merge(t *testing.T) {
+	t.Parallel()
+	global := newLeaderboard(5)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			local := newLeaderboard(5)
+			for i := 0; i < 100; i++ {
+				local.add(detect.Match{Detector: "palindrome", Pos: int64(w*1000 + i), Length: i % 20})
+			}
+			global.merge(local)
+			local.reset()
+		}(w)
+	}
+	wg.Wait()
+
+	res := global.boards["palindrome"].sorted()
+	require.Len(t, res, 5)
+	// Length 19 is found at i = 19, 39, 59, 79, 99 by each worker.
+	for i, pos := range []int64{19, 39, 59, 79, 99} {
+		assert.Equal(t, 19, res[i].Length)
+		assert.Equal(t, pos, res[i].Pos)
+	}
+}
+
+func TestLeaderboard_Persist(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "leaderboard.txt")
+	l := newLeaderboard(1)
+	l.add(detect.Match{Detector: "palindrome", Pos: 0, Length: 1, Digits: "1", IntegerDigits: 1})
+	require.NoError(t, l.persist(path))
+	l.add(detect.Match{Detector: "palindrome", Pos: 1, Length: 3, Digits: "454", IntegerDigits: 1})
+	require.NoError(t, l.persist(path))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# palindrome\n1, 3, 2, 454\n", string(content))
+
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
@@ -1,19 +1,24 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
-	"strconv"
 
 	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/detect"
 	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
-	"github.com/googlecloudplatform/pi-delivery/pkg/obj/gcs"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/objflags"
+	"github.com/googlecloudplatform/pi-delivery/pkg/stats"
 	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
 	"github.com/sethvargo/go-retry"
 	"go.uber.org/zap"
@@ -21,88 +26,142 @@ import (
 
 const (
 	CHUNK_SIZE        = 100_000_000
+	// OVERLAP is the number of digits read on each side of a chunk so
+	// matches around chunk boundaries are found.
+	OVERLAP           = 1000
 	WORKERS           = 150
 )
 
 var logger *zap.SugaredLogger
 var wg sync.WaitGroup
 
+// board is the leaderboard of the whole run. Workers merge their findings
+// into it after each task.
+var board *leaderboard
+
+// digitStats are the digit statistics of the whole run, nil unless -stats is set.
+var digitStats *stats.Table
+
 type workerContextKey string
 
+// detectors are run over every chunk.
+var detectors []detect.Detector
+
+// set is the result set scanned, index.Decimal or index.Hexadecimal (-radix).
+var set = index.Decimal
+
+// outDir is the directory results are written to. Hexadecimal results go to
+// a subdirectory so they aren't mixed with the decimal ones.
+var outDir = "full_results"
+
 type task struct {
+	// start and n delimit the digits owned by the task.
 	start  int64
-	n      int32
+	n      int64
 	cancel context.CancelFunc
-	unp 	 string
-	offset int
-	out    io.Writer
 	id 		 int64
+	board  *leaderboard
+	stats  *stats.Table
+}
+
+// outputFile returns the file the matches of detector d in the task are
+// written to. Palindromes keep the batch-%d.txt name read by pi-verify.
+func (t *task) outputFile(d detect.Detector) string {
+	if d.Name() == "palindrome" {
+		return filepath.Join(outDir, fmt.Sprintf("batch-%d.txt", t.id))
+	}
+	return filepath.Join(outDir, fmt.Sprintf("%s-batch-%d.txt", d.Name(), t.id))
+}
+
+// writeMatch writes a result line for m found in c.
+// Palindrome lines have the chunk start, the center of the palindrome in the
+// chunk, the digits and the length. Other lines have the position, length and digits.
+func writeMatch(w io.Writer, c *detect.Chunk, m detect.Match) {
+	if m.Detector == "palindrome" {
+		center := m.Pos + int64(m.Length-1)/2
+		fmt.Fprintf(w, "%d, %d, %s, %d", c.Start, center-c.Start, m.Digits, m.Length)
+	} else {
+		fmt.Fprintf(w, "%d, %d, %s", m.Position(), m.Length, m.Digits)
+	}
+	if m.Note != "" {
+		fmt.Fprintf(w, ", %s", m.Note)
+	}
+	fmt.Fprint(w, " \n")
 }
 
 func process(ctx context.Context, task *task, logger *zap.SugaredLogger, client obj.Client) error {
 	logger.Infof("processing task, start = %d, n = %v", task.start, task.n)
+	// Drop findings of a failed attempt.
+	task.board.reset()
+	if task.stats != nil {
+		task.stats.Reset()
+	}
 
-	rrd := index.Decimal.NewReader(ctx, client.Bucket(index.BucketName))
+	start := task.start - OVERLAP
+	if start < 0 {
+		start = 0
+	}
+	rrd := set.NewReader(ctx, client.Bucket(index.BucketName))
 	defer rrd.Close()
 	urd := unpack.NewReader(ctx, rrd)
-	if _, err := urd.Seek(task.start, io.SeekStart); err != nil {
+	if _, err := urd.Seek(start, io.SeekStart); err != nil {
 		return err
 	}
 	var buf bytes.Buffer
-
-	if _, err := io.CopyN(&buf, urd, CHUNK_SIZE); err != nil {
-		fmt.Fprintf(os.Stderr, "I/O error: %v\n", err)
-		os.Exit(1)
+	if _, err := io.CopyN(&buf, urd, task.start+task.n+OVERLAP-start); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("I/O error: %w", err)
 	}
 
-	task.unp = buf.String()
-
-	outfile := fmt.Sprintf("full_results/batch-%d.txt", task.id)
-	f, err := os.OpenFile(outfile, os.O_CREATE|os.O_RDWR, 0644)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "couldn't open %s: %v", outfile, err)
-		os.Exit(1)
+	hi := task.start + task.n - start
+	if hi > int64(buf.Len()) {
+		hi = int64(buf.Len())
 	}
-	defer f.Close()
-	task.out = f
-
-	FindCandidates(task)
+	for _, d := range detectors {
+		chunk := &detect.Chunk{
+			Start:         start,
+			Digits:        buf.Bytes(),
+			Lo:            int(task.start - start),
+			Hi:            int(hi),
+			Radix:         set.Radix(),
+			Source:        urd,
+			IntegerDigits: len(set.IntegerPart()),
+		}
 
+		outfile := task.outputFile(d)
+		f, err := os.OpenFile(outfile, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "couldn't open %s: %v", outfile, err)
+			os.Exit(1)
+		}
+		out := bufio.NewWriter(f)
+		err = d.Detect(chunk, func(m detect.Match) {
+			writeMatch(out, chunk, m)
+			task.board.add(m)
+		})
+		if ferr := out.Flush(); err == nil {
+			err = ferr
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.Name(), err)
+		}
+	}
+	if task.stats != nil {
+		lo := int(task.start - start)
+		if err := task.stats.Add(task.start, buf.Bytes()[lo:], int(hi)-lo); err != nil {
+			return fmt.Errorf("stats: %w", err)
+		}
+		digitStats.Merge(task.stats)
+	}
+	board.merge(task.board)
 
 	logger.Infof("digits processed: %d + %d digits",
 		task.start, task.n)
 	return nil
 }
 
-func FindCandidates(b *task) {
-  s := b.unp
-  ps := b.offset
-  for i := range s {
-    if i >= (len(s)-1)-ps { break }
-    if i-ps < 0 { continue }
-    pLen := ps
-
-    for i-pLen >= 0 && i+pLen < len(s) && s[i-pLen] == s[i+pLen] {
-      pLen++
-    }
-
-    if i - pLen > 0 && pLen > 8  {
-        pal := s[i-pLen+1 : i+pLen]
-        index := toString(i)
-        fmt.Fprintf(b.out, "%d, %s, %s, %d \n", b.start, index, pal, len(pal))
-      }
-  }
-}
-
-func toString(i int) string {
-	var s interface{} = i
-	if _, ok := s.(int64); ok {
-		return strconv.FormatInt(int64(i), 10)
-	} else {
-		return strconv.Itoa(i)
-	}
-}
-
 func worker(ctx context.Context, taskChan <-chan task, client obj.Client) {
 	defer wg.Done()
 	logger := logger.With("worker id", ctx.Value(workerContextKey("workerId")))
@@ -111,7 +170,14 @@ func worker(ctx context.Context, taskChan <-chan task, client obj.Client) {
 
 	logger.Info("worker started")
 	b := retry.WithMaxRetries(3, retry.NewExponential(1*time.Second))
+	local := newLeaderboard(board.k)
+	var localStats *stats.Table
+	if digitStats != nil {
+		localStats = stats.NewTable(set.Radix(), set.BlockSize())
+	}
 	for task := range taskChan {
+		task.board = local
+		task.stats = localStats
 		select {
 		case <-ctx.Done():
 			return
@@ -138,16 +204,123 @@ func main() {
 	logger = l.Sugar()
 
 	start := flag.Int64("s", 0, "Start offset")
+	// The digits are always read from GCS.
+	src := new(objflags.Flags)
+	src.RegisterCache(flag.CommandLine, 10<<30)
+	topK := flag.Int("k", 10, "Number of top matches to keep per detector")
+	boardFile := flag.String("leaderboard", "", "Leaderboard file (default leaderboard.txt in the results directory)")
+	boardInterval := flag.Duration("leaderboard-interval", 5*time.Minute, "How often to save the leaderboard and the statistics")
+	prime := flag.String("prime", "", "Check palindromes for primality: 'tag' marks each one as prime or composite, 'filter' only keeps primes")
+	detectorNames := flag.String("detectors", "palindrome", "Comma separated detectors to run: palindrome, run, self-locating, k-palindrome, progression, tandem")
+	palindromeMin := flag.Int("palindrome-min", 17, "Minimum length of reported palindromes")
+	runMin := flag.Int("run-min", 12, "Minimum length of reported runs of a single digit")
+	radix := flag.Int("radix", 10, "Radix of the digits to scan: 10 or 16. Hexadecimal results are written to full_results/hex")
+	kpalK := flag.Int("kpal-k", 2, "Maximum number of mismatched pairs in near-palindromes")
+	kpalMin := flag.Int("kpal-min", 27, "Minimum length of reported near-palindromes")
+	progressionMin := flag.Int("progression-min", 10, "Minimum length of reported arithmetic progressions of digits")
+	tandemMaxPeriod := flag.Int("tandem-max-period", 100, "Largest period of reported tandem repeats (at most the chunk overlap)")
+	tandemMin := flag.Int("tandem-min", 20, "Minimum total length of reported tandem repeats")
+	statsEnabled := flag.Bool("stats", false, "Count digits and n-grams and write statistics reports per block file and for the whole run")
 	flag.Parse()
 
+	switch *radix {
+	case 10:
+	case 16:
+		set = index.Hexadecimal
+		outDir = filepath.Join(outDir, "hex")
+	default:
+		logger.Errorf("unsupported radix: %d", *radix)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		logger.Errorf("couldn't create %s: %v", outDir, err)
+		os.Exit(1)
+	}
+	if *boardFile == "" {
+		*boardFile = filepath.Join(outDir, "leaderboard.txt")
+	}
+
+	switch detect.PrimeMode(*prime) {
+	case detect.PrimeOff, detect.PrimeTag, detect.PrimeFilter:
+	default:
+		logger.Errorf("unknown -prime mode: %s", *prime)
+		os.Exit(1)
+	}
+	if *tandemMaxPeriod > OVERLAP {
+		logger.Errorf("-tandem-max-period must be at most %d", OVERLAP)
+		os.Exit(1)
+	}
+	if *topK < 0 {
+		logger.Errorf("-k must not be negative")
+		os.Exit(1)
+	}
+	if *kpalK < 0 {
+		logger.Errorf("-kpal-k must not be negative")
+		os.Exit(1)
+	}
+	if *kpalMin < 1 {
+		logger.Errorf("-kpal-min must be at least 1")
+		os.Exit(1)
+	}
+	for _, name := range strings.Split(*detectorNames, ",") {
+		switch strings.TrimSpace(name) {
+		case "palindrome":
+			detectors = append(detectors, &detect.Palindromes{MinLength: *palindromeMin, Prime: detect.PrimeMode(*prime)})
+		case "run":
+			detectors = append(detectors, &detect.Runs{MinLength: *runMin})
+		case "self-locating":
+			detectors = append(detectors, &detect.SelfLocating{})
+		case "k-palindrome":
+			detectors = append(detectors, &detect.KMismatchPalindromes{K: *kpalK, MinLength: *kpalMin})
+		case "progression":
+			detectors = append(detectors, &detect.Progressions{MinLength: *progressionMin})
+		case "tandem":
+			detectors = append(detectors, &detect.TandemRepeats{MaxPeriod: *tandemMaxPeriod, MinLength: *tandemMin})
+		default:
+			logger.Errorf("unknown detector: %s", name)
+			os.Exit(1)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	client, err := gcs.NewClient(ctx)
+	client, err := src.NewClient(ctx)
 	if err != nil {
-		logger.Errorf("couldn't create a GCS client: %v", err)
+		logger.Error(err)
 		os.Exit(1)
 	}
 	defer client.Close()
 
+	board = newLeaderboard(*topK)
+	if *statsEnabled {
+		digitStats = stats.NewTable(set.Radix(), set.BlockSize())
+	}
+	saveStats := func() {
+		if digitStats == nil {
+			return
+		}
+		if err := persistStats(digitStats, outDir); err != nil {
+			logger.Errorw("couldn't save the statistics", "error", err)
+		}
+	}
+	stopPersist := make(chan struct{})
+	persistDone := make(chan struct{})
+	go func() {
+		defer close(persistDone)
+		ticker := time.NewTicker(*boardInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := board.persist(*boardFile); err != nil {
+					logger.Errorw("couldn't save the leaderboard", "error", err)
+				}
+				saveStats()
+			case <-stopPersist:
+				return
+			}
+		}
+	}()
+
 	taskChan := make(chan task, 150)
 
 	for i := 0; i < WORKERS; i++ {
@@ -156,19 +329,11 @@ func main() {
 		go worker(ctx, taskChan, client)
 	}
 
-	for i := *start; i < index.Decimal.TotalDigits(); i += CHUNK_SIZE {
-		var s int64
-		if i == 0 {
-			s = i
-		} else {
-			s = i - 1000
-		}
-
+	for i := *start; i < set.TotalDigits(); i += CHUNK_SIZE {
 		task := task{
-			start:  s,
+			start:  i,
 			n:      CHUNK_SIZE,
 			cancel: cancel,
-			offset: 1,
 			id:     i,
 		}
 		taskChan <- task
@@ -179,4 +344,12 @@ func main() {
 	}
 	close(taskChan)
 	wg.Wait()
+
+	close(stopPersist)
+	<-persistDone
+	if err := board.persist(*boardFile); err != nil {
+		logger.Errorw("couldn't save the leaderboard", "error", err)
+	}
+	saveStats()
+	board.write(os.Stdout)
 }
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/stats"
+)
+
+// persistStats writes the statistics report of every block file to
+// dir/stats-block-N.txt and of the whole run to dir/stats.txt.
+func persistStats(t *stats.Table, dir string) error {
+	for _, id := range t.Blocks() {
+		path := filepath.Join(dir, fmt.Sprintf("stats-block-%d.txt", id))
+		if err := writeAtomic(path, t.Block(id).WriteReport); err != nil {
+			return err
+		}
+	}
+	return writeAtomic(filepath.Join(dir, "stats.txt"), t.Total().WriteReport)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/stats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats_Persist(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	table := stats.NewTable(10, 10)
+	digits := []byte("14159265358979323846")
+	require.NoError(t, table.Add(0, digits, 20))
+	require.NoError(t, persistStats(table, dir))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"stats-block-0.txt", "stats-block-1.txt", "stats.txt"}, names)
+
+	for name, n := range map[string]string{"stats-block-0.txt": "10", "stats-block-1.txt": "10", "stats.txt": "20"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(content), "radix: 10\ndigits: "+n+"\n"), name)
+	}
+}
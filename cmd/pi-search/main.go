@@ -0,0 +1,114 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command pi-search prints the first occurrences of digit strings in pi.
+//
+//	go run ./cmd/pi-search -n 3 20220314 0123456789
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/objflags"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/search"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"go.uber.org/zap"
+)
+
+var logger *zap.SugaredLogger
+
+func main() {
+	l, _ := zap.NewDevelopment()
+	defer l.Sync()
+	zap.ReplaceGlobals(l)
+	logger = l.Sugar()
+
+	patternList := flag.String("p", "", "Comma separated patterns to search for (also accepted as arguments)")
+	patternFile := flag.String("f", "", "File with one pattern per line")
+	n := flag.Int("n", 1, "Number of occurrences to find per pattern")
+	start := flag.Int64("s", 0, "Digit offset to start searching at (0 is the first digit after the decimal point)")
+	length := flag.Int64("length", 0, "Maximum number of digits to search (0 searches to the end)")
+	radix := flag.Int("radix", 10, "Radix of the digits and patterns: 10 or 16")
+	src := objflags.Register(flag.CommandLine, index.BucketName)
+	src.RegisterCache(flag.CommandLine, 0)
+	flag.Parse()
+
+	patterns := append(splitPatterns(*patternList), flag.Args()...)
+	if *patternFile != "" {
+		f, err := os.Open(*patternFile)
+		if err != nil {
+			logger.Errorf("couldn't open %s: %v", *patternFile, err)
+			os.Exit(1)
+		}
+		fromFile, err := readPatterns(f)
+		f.Close()
+		if err != nil {
+			logger.Errorf("couldn't read %s: %v", *patternFile, err)
+			os.Exit(1)
+		}
+		patterns = append(patterns, fromFile...)
+	}
+
+	var set resultset.ResultSet
+	switch *radix {
+	case 10:
+		set = index.Decimal
+	case 16:
+		set = index.Hexadecimal
+	default:
+		logger.Errorf("unsupported radix: %d", *radix)
+		os.Exit(1)
+	}
+	m, err := search.NewMatcher(patterns, *radix)
+	if err != nil {
+		logger.Errorf("%v", err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client, err := src.NewClient(ctx)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	rrd := set.NewReader(ctx, client.Bucket(src.Bucket))
+	defer rrd.Close()
+	urd := unpack.NewReader(ctx, rrd)
+	if _, err := urd.Seek(*start, io.SeekStart); err != nil {
+		logger.Errorf("couldn't seek to %d: %v", *start, err)
+		os.Exit(1)
+	}
+	var rd io.Reader = urd
+	if *length > 0 {
+		rd = io.LimitReader(urd, *length)
+	}
+
+	logger.Infow("searching", "patterns", len(patterns), "start", *start, "length", *length)
+	res, err := m.FirstN(rd, *start, *n)
+	if err != nil {
+		logger.Errorf("search failed: %v", err)
+		os.Exit(1)
+	}
+	if err := writeResults(os.Stdout, set, m.Patterns(), res); err != nil {
+		logger.Errorf("couldn't write the results: %v", err)
+		os.Exit(1)
+	}
+}
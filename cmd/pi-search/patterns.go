@@ -0,0 +1,67 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/search"
+)
+
+// readPatterns reads one pattern per line from rd.
+// Empty lines and lines starting with # are skipped.
+func readPatterns(rd io.Reader) ([]string, error) {
+	var res []string
+	scanner := bufio.NewScanner(rd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		res = append(res, line)
+	}
+	return res, scanner.Err()
+}
+
+// splitPatterns splits a comma separated list of patterns.
+func splitPatterns(s string) []string {
+	var res []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// writeResults writes one line per occurrence with the pattern and its
+// position in set, or "not found" for patterns without any occurrence.
+func writeResults(w io.Writer, set resultset.ResultSet, patterns []string, res [][]search.Occurrence) error {
+	bw := bufio.NewWriter(w)
+	for i, p := range patterns {
+		if len(res[i]) == 0 {
+			fmt.Fprintf(bw, "%s, not found\n", p)
+			continue
+		}
+		for _, o := range res[i] {
+			fmt.Fprintf(bw, "%s, %d\n", p, set.Position(o.Pos))
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/search"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatterns_Read(t *testing.T) {
+	t.Parallel()
+	res, err := readPatterns(strings.NewReader("# birthdays\n19700101\n\n  0314 \r\n#\n999999"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"19700101", "0314", "999999"}, res)
+
+	assert.Equal(t, []string{"1", "23", "456"}, splitPatterns(" 1,23,,456 "))
+	assert.Empty(t, splitPatterns(""))
+}
+
+func TestPatterns_WriteResults(t *testing.T) {
+	t.Parallel()
+	m, err := search.NewMatcher([]string{"26", "999999", "0000"}, 10)
+	require.NoError(t, err)
+	const digits = "1415926535897932384626433832795028841971693993751058209749445923078164062862089986280348253421170679"
+	res, err := m.FirstN(strings.NewReader(digits), 0, 2)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeResults(&buf, index.Decimal, m.Patterns(), res))
+	assert.Equal(t, "26, 6\n26, 21\n999999, not found\n0000, not found\n", buf.String())
+
+	// sqrt(200) = 14.142135623730950488016887242096980785696...
+	f, err := tests.NewConstantFixture("sqrt200", "14.142135623730950488016887242096980785696", 10, 20)
+	require.NoError(t, err)
+	m, err = search.NewMatcher([]string{"0950"}, 10)
+	require.NoError(t, err)
+	res, err = m.FirstN(strings.NewReader(f.Digits), 0, 2)
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, writeResults(&buf, f.Set, m.Patterns(), res))
+	assert.Equal(t, "0950, 13\n", buf.String())
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/goccy/go-json"
+	"github.com/googlecloudplatform/pi-delivery/pkg/service"
+	"go.uber.org/zap"
+)
+
+// Defaults of the query parameters, same as api.pi.delivery.
+const (
+	defaultStart          = 0
+	defaultNumberOfDigits = 100
+	defaultRadix          = 10
+)
+
+type piResponse struct {
+	Content string `json:"content"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type piHandler struct {
+	service  *service.Service
+	constant string
+	logger   *zap.SugaredLogger
+}
+
+func parseParam(r *http.Request, key string, def int64) (int64, error) {
+	v := r.URL.Query().Get(key)
+	if v == "" {
+		return def, nil
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %q is not an integer", service.ErrInvalidArgument, key, v)
+	}
+	return i, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *piHandler) writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidArgument),
+		errors.Is(err, service.ErrOutOfRange),
+		errors.Is(err, service.ErrUnknownResultSet):
+		code = http.StatusBadRequest
+	default:
+		h.logger.Errorw("request failed", "error", err)
+		err = errors.New("internal error")
+	}
+	writeJSON(w, code, &errorResponse{Error: err.Error()})
+}
+
+// ServeHTTP serves /v1/pi?start=&numberOfDigits=&radix=.
+func (h *piHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{Error: "method not allowed"})
+		return
+	}
+	start, err := parseParam(r, "start", defaultStart)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	n, err := parseParam(r, "numberOfDigits", defaultNumberOfDigits)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	radix, err := parseParam(r, "radix", defaultRadix)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if radix != 10 && radix != 16 {
+		h.writeError(w, fmt.Errorf("%w: radix must be 10 or 16: %d", service.ErrInvalidArgument, radix))
+		return
+	}
+
+	name := service.EntryName(h.constant, int(radix))
+	digits, err := h.service.GetByName(r.Context(), name, start, n)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &piResponse{Content: string(digits)})
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/local"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/service"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testDigits = "14159265358979323846264338327950288419"
+
+func newTestHandler(t *testing.T) *piHandler {
+	root := t.TempDir()
+	const name = "Pi - Dec - Chudnovsky/Pi - Dec - Chudnovsky - 0.ycd"
+
+	packed := make([]byte, 2*ycd.WordSize)
+	for i := 0; i < 2; i++ {
+		word, err := strconv.ParseUint(testDigits[i*19:(i+1)*19], 10, 64)
+		require.NoError(t, err)
+		binary.LittleEndian.PutUint64(packed[i*ycd.WordSize:], word)
+	}
+	path := filepath.Join(root, "bucket", filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, packed, 0644))
+
+	set := resultset.ResultSet{
+		{
+			Header: &ycd.Header{
+				FileVersion: "1.1.0",
+				Radix:       10,
+				FirstDigits: "3." + testDigits,
+				BlockSize:   int64(len(testDigits)),
+			},
+			Name: name,
+		},
+	}
+	l, _ := zap.NewDevelopment()
+	svc := service.NewServiceWithClient(l.Sugar(), local.NewClient(root), "bucket")
+	t.Cleanup(func() { svc.Close() })
+	require.NoError(t, svc.Register("pi-dec", set, "bucket"))
+
+	return &piHandler{
+		service:  svc,
+		constant: "pi",
+		logger:   l.Sugar(),
+	}
+}
+
+func TestPiHandler(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler(t)
+
+	testCases := []struct {
+		query    string
+		code     int
+		expected string
+	}{
+		{"", http.StatusOK, "3" + testDigits},
+		{"?start=0&numberOfDigits=10&radix=10", http.StatusOK, "3141592653"},
+		{"?start=1&numberOfDigits=5", http.StatusOK, "14159"},
+		{"?start=30&numberOfDigits=100", http.StatusOK, testDigits[29:]},
+		{"?start=100", http.StatusBadRequest, ""},
+		{"?start=-1", http.StatusBadRequest, ""},
+		{"?numberOfDigits=1001", http.StatusBadRequest, ""},
+		{"?start=abc", http.StatusBadRequest, ""},
+		{"?radix=8", http.StatusBadRequest, ""},
+		{"?radix=16", http.StatusBadRequest, ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/pi"+tc.query, nil))
+			assert.Equal(t, tc.code, w.Code)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			if tc.code != http.StatusOK {
+				var res errorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+				assert.NotEmpty(t, res.Error)
+				return
+			}
+			var res piResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Equal(t, tc.expected, res.Content)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/pi", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
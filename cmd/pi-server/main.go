@@ -0,0 +1,71 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command pi-server serves digits over HTTP with the same API as
+// https://api.pi.delivery/v1/pi, reading from a bucket or a local copy of it.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/objflags"
+	"github.com/googlecloudplatform/pi-delivery/pkg/service"
+	"go.uber.org/zap"
+)
+
+func main() {
+	l, _ := zap.NewDevelopment()
+	defer l.Sync()
+	zap.ReplaceGlobals(l)
+	logger := l.Sugar()
+
+	addr := flag.String("addr", ":8080", "Address to listen on")
+	src := objflags.Register(flag.CommandLine, index.BucketName)
+	src.RegisterCache(flag.CommandLine, 10<<30)
+	flag.Parse()
+
+	ctx := context.Background()
+	client, err := src.NewClient(ctx)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+
+	svc := service.NewServiceWithClient(logger, client, src.Bucket)
+	defer svc.Close()
+	constant := index.Decimal.Constant()
+	if err := svc.Register(service.EntryName(constant, 10), index.Decimal, src.Bucket); err != nil {
+		logger.Fatalw("couldn't register the decimal result set", "error", err)
+	}
+	if err := svc.Register(service.EntryName(constant, 16), index.Hexadecimal, src.Bucket); err != nil {
+		logger.Fatalw("couldn't register the hexadecimal result set", "error", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/"+constant, &piHandler{
+		service:  svc,
+		constant: constant,
+		logger:   logger,
+	})
+
+	logger.Infow("listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, mux); err != nil {
+		logger.Errorf("server error: %v", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+)
+
+// Status is the verification result of a candidate.
+type Status string
+
+const (
+	StatusOK            Status = "ok"
+	StatusMismatch      Status = "mismatch"
+	StatusNotPalindrome Status = "not-palindrome"
+	StatusBadLength     Status = "bad-length"
+	StatusError         Status = "error"
+)
+
+// candidate is a palindrome reported by pi-processor.
+// Result lines have the form "start, index, digits, length" where start is
+// the digit offset of the chunk and index is the center of the palindrome
+// in the chunk.
+type candidate struct {
+	File   string
+	Line   int
+	Start  int64
+	Index  int64
+	Digits string
+	Size   int
+
+	Status Status
+	Detail string
+}
+
+// Offset returns the offset of the first digit of the palindrome as read by
+// unpack.UnpackReader (the first digit after the decimal point is 0).
+func (c *candidate) Offset() int64 {
+	return c.Start + c.Index - int64(c.Size-1)/2
+}
+
+func parseCandidate(line string) (*candidate, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("expected 4 fields: %q", line)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	start, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	index, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	size, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, fmt.Errorf("length: %w", err)
+	}
+	return &candidate{
+		Start:  start,
+		Index:  index,
+		Digits: fields[2],
+		Size:   size,
+	}, nil
+}
+
+// readCandidates parses result lines from rd. Empty lines are skipped.
+func readCandidates(rd io.Reader, file string) ([]*candidate, error) {
+	var res []*candidate
+	scanner := bufio.NewScanner(rd)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		c, err := parseCandidate(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		c.File = file
+		c.Line = n
+		res = append(res, c)
+	}
+	return res, scanner.Err()
+}
+
+func isPalindrome(s string) bool {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		if s[i] != s[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// verify fetches the digits of c from rd and sets c.Status.
+// rd must return unpacked digits at offsets as unpack.UnpackReader does.
+func verify(c *candidate, rd io.ReaderAt) {
+	if len(c.Digits) != c.Size {
+		c.Status = StatusBadLength
+		c.Detail = fmt.Sprintf("%d digits reported as %d", len(c.Digits), c.Size)
+		return
+	}
+	if !isPalindrome(c.Digits) {
+		c.Status = StatusNotPalindrome
+		return
+	}
+	if c.Offset() < 0 {
+		c.Status = StatusError
+		c.Detail = fmt.Sprintf("negative offset %d", c.Offset())
+		return
+	}
+	buf := make([]byte, c.Size)
+	n, err := rd.ReadAt(buf, c.Offset())
+	if n < len(buf) {
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		c.Status = StatusError
+		c.Detail = err.Error()
+		return
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		c.Status = StatusError
+		c.Detail = err.Error()
+		return
+	}
+	if string(buf) != c.Digits {
+		c.Status = StatusMismatch
+		c.Detail = fmt.Sprintf("found %s", buf)
+		return
+	}
+	c.Status = StatusOK
+}
+
+// mayBePrime reports whether the last digit of c in radix doesn't rule out a
+// prime: a number longer than one digit is composite if its last digit shares
+// a factor with the radix, e.g. 0, 2, 4, 5, 6 or 8 in decimal and the even
+// digits in hexadecimal.
+func (c *candidate) mayBePrime(radix int) bool {
+	if len(c.Digits) == 0 {
+		return false
+	}
+	if len(c.Digits) == 1 {
+		return true
+	}
+	d := unpack.DigitValue(c.Digits[len(c.Digits)-1], radix)
+	if d < 0 {
+		return false
+	}
+	for f := 2; f <= d; f++ {
+		if d%f == 0 && radix%f == 0 {
+			return false
+		}
+	}
+	return d != 0
+}
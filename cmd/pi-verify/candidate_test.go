@@ -0,0 +1,108 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The first digits after the decimal point.
+const testDigits = "14159265358979323846264338327950288419716939937510" +
+	"58209749445923078164062862089986280348253421170679"
+
+func TestCandidate_Parse(t *testing.T) {
+	t.Parallel()
+	input := "0, 23, 46264, 5 \n\n99999000, 1011, 3833, 4 \n"
+	cands, err := readCandidates(strings.NewReader(input), "batch-0.txt")
+	require.NoError(t, err)
+	require.Len(t, cands, 2)
+
+	assert.Equal(t, &candidate{
+		File:   "batch-0.txt",
+		Line:   1,
+		Start:  0,
+		Index:  23,
+		Digits: "46264",
+		Size:   5,
+	}, cands[0])
+	assert.Equal(t, int64(21), cands[0].Offset())
+	assert.Equal(t, int64(22), index.Decimal.Position(cands[0].Offset()))
+	assert.Equal(t, 3, cands[1].Line)
+	assert.Equal(t, int64(99999000+1011-1), cands[1].Offset())
+
+	_, err = readCandidates(strings.NewReader("1, 2, 3\n"), "bad.txt")
+	assert.Error(t, err)
+	_, err = readCandidates(strings.NewReader("a, 2, 3, 1\n"), "bad.txt")
+	assert.Error(t, err)
+}
+
+func TestCandidate_Verify(t *testing.T) {
+	t.Parallel()
+	rd := bytes.NewReader([]byte(testDigits))
+
+	testCases := []struct {
+		name   string
+		c      candidate
+		status Status
+	}{
+		// 46264 starts at offset 18.
+		{"ok", candidate{Start: 0, Index: 20, Digits: "46264", Size: 5}, StatusOK},
+		{"ok in a later chunk", candidate{Start: 10, Index: 10, Digits: "46264", Size: 5}, StatusOK},
+		{"wrong position", candidate{Start: 0, Index: 21, Digits: "46264", Size: 5}, StatusMismatch},
+		{"not a palindrome", candidate{Start: 0, Index: 20, Digits: "46265", Size: 5}, StatusNotPalindrome},
+		{"bad length", candidate{Start: 0, Index: 20, Digits: "46264", Size: 7}, StatusBadLength},
+		{"past the end", candidate{Start: 0, Index: 99, Digits: "00700", Size: 5}, StatusError},
+		{"negative offset", candidate{Start: 0, Index: 1, Digits: "00700", Size: 5}, StatusError},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			verify(&tc.c, rd)
+			assert.Equal(t, tc.status, tc.c.Status, tc.c.Detail)
+		})
+	}
+}
+
+func TestCandidate_Report(t *testing.T) {
+	t.Parallel()
+	cands := []*candidate{
+		{File: "a", Line: 1, Start: 0, Index: 23, Digits: "46264", Size: 5, Status: StatusOK},
+		{File: "b", Line: 2, Start: 0, Index: 10, Digits: "979", Size: 3, Status: StatusOK},
+		{File: "c", Line: 3, Start: 0, Index: 30, Digits: "1234321", Size: 7, Status: StatusMismatch, Detail: "found 0000000"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, index.Decimal, cands))
+	assert.Equal(t,
+		"mismatch, 28, 1234321, 7, c:3, found 0000000\n"+
+			"ok, 22, 46264, 5, a:1\n"+
+			"ok, 10, 979, 3, b:2\n",
+		buf.String())
+
+	assert.True(t, (&candidate{Digits: "12321"}).mayBePrime(10))
+	assert.False(t, (&candidate{Digits: "21512"}).mayBePrime(10))
+	assert.False(t, (&candidate{Digits: "50805"}).mayBePrime(10))
+	assert.True(t, (&candidate{Digits: "5"}).mayBePrime(10))
+	assert.True(t, (&candidate{Digits: "50805"}).mayBePrime(16))
+	assert.False(t, (&candidate{Digits: "a1b1a"}).mayBePrime(16))
+	assert.True(t, (&candidate{Digits: "b1a1b"}).mayBePrime(16))
+	assert.False(t, (&candidate{Digits: "b1a1b"}).mayBePrime(10))
+}
@@ -0,0 +1,189 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command pi-verify re-reads the digits of every palindrome found by
+// pi-processor and writes a report with the verification status of each one.
+// With -radix 16 it verifies the hexadecimal results in full_results/hex.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/objflags"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"go.uber.org/zap"
+)
+
+var logger *zap.SugaredLogger
+
+func loadCandidates(pattern string, minSize int, primeOnly bool, radix int) ([]*candidate, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var res []*candidate
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		cands, err := readCandidates(f, file)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cands {
+			if c.Size < minSize || (primeOnly && !c.mayBePrime(radix)) {
+				continue
+			}
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+func verifyAll(cands []*candidate, rd io.ReaderAt, workers int) {
+	ch := make(chan *candidate)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range ch {
+				verify(c, rd)
+			}
+		}()
+	}
+	for _, c := range cands {
+		ch <- c
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// writeReport writes one line per candidate, longest first, with positions
+// numbered by set.Position.
+func writeReport(w io.Writer, set resultset.ResultSet, cands []*candidate) error {
+	sort.SliceStable(cands, func(i, j int) bool {
+		if cands[i].Size != cands[j].Size {
+			return cands[i].Size > cands[j].Size
+		}
+		return cands[i].Offset() < cands[j].Offset()
+	})
+	bw := bufio.NewWriter(w)
+	for _, c := range cands {
+		fmt.Fprintf(bw, "%s, %d, %s, %d, %s:%d", c.Status, set.Position(c.Offset()), c.Digits, c.Size, c.File, c.Line)
+		if c.Detail != "" {
+			fmt.Fprintf(bw, ", %s", c.Detail)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+func main() {
+	l, _ := zap.NewDevelopment()
+	defer l.Sync()
+	zap.ReplaceGlobals(l)
+	logger = l.Sugar()
+
+	radix := flag.Int("radix", 10, "Radix of the results to verify: 10 or 16, as passed to pi-processor")
+	in := flag.String("in", "", "Glob of result files to verify (default batch-*.txt in the results directory)")
+	out := flag.String("out", "", "Report file (default verified.txt in the results directory)")
+	minSize := flag.Int("min-size", 0, "Only verify palindromes with at least this many digits")
+	primeOnly := flag.Bool("prime-candidates", false, "Skip palindromes whose last digit shares a factor with the radix, e.g. 0, 2, 4, 5, 6 or 8 in decimal")
+	workers := flag.Int("workers", 16, "Number of concurrent reads")
+	src := objflags.Register(flag.CommandLine, index.BucketName)
+	src.RegisterCache(flag.CommandLine, 0)
+	flag.Parse()
+
+	// The results directory of pi-processor for the radix.
+	dir := "full_results"
+	var set resultset.ResultSet
+	switch *radix {
+	case 10:
+		set = index.Decimal
+	case 16:
+		set = index.Hexadecimal
+		dir = filepath.Join(dir, "hex")
+	default:
+		logger.Errorf("unsupported radix: %d", *radix)
+		os.Exit(1)
+	}
+	if *in == "" {
+		*in = filepath.Join(dir, "batch-*.txt")
+	}
+	if *out == "" {
+		*out = filepath.Join(dir, "verified.txt")
+	}
+
+	cands, err := loadCandidates(*in, *minSize, *primeOnly, *radix)
+	if err != nil {
+		logger.Errorf("couldn't read results: %v", err)
+		os.Exit(1)
+	}
+	logger.Infof("verifying %d candidates", len(cands))
+
+	ctx := context.Background()
+	client, err := src.NewClient(ctx)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	rrd := set.NewReader(ctx, client.Bucket(src.Bucket))
+	defer rrd.Close()
+	verifyAll(cands, unpack.NewReader(ctx, rrd), *workers)
+
+	f, err := os.Create(*out)
+	if err != nil {
+		logger.Errorf("couldn't create %s: %v", *out, err)
+		os.Exit(1)
+	}
+	if err := writeReport(f, set, cands); err != nil {
+		logger.Errorf("couldn't write %s: %v", *out, err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		logger.Errorf("couldn't write %s: %v", *out, err)
+		os.Exit(1)
+	}
+
+	counts := make(map[Status]int)
+	for _, c := range cands {
+		counts[c.Status]++
+	}
+	logger.Infow("verification finished",
+		"report", *out,
+		string(StatusOK), counts[StatusOK],
+		string(StatusMismatch), counts[StatusMismatch],
+		string(StatusNotPalindrome), counts[StatusNotPalindrome],
+		string(StatusBadLength), counts[StatusBadLength],
+		string(StatusError), counts[StatusError],
+	)
+	if len(cands) != counts[StatusOK] {
+		os.Exit(2)
+	}
+}
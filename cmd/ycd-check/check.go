@@ -0,0 +1,312 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+)
+
+var (
+	errHeader        = errors.New("header doesn't match the index")
+	errSize          = errors.New("size mismatch")
+	errTruncated     = errors.New("truncated")
+	errRead          = errors.New("read failed")
+	errInvalidWords  = errors.New("invalid words")
+	errPadding       = errors.New("nonzero padding after the last digit (assumed zero)")
+	errFirstDigits   = errors.New("first digits don't match block 0")
+	errNoFirstDigits = errors.New("first digits can't be checked")
+)
+
+// defaultChunkSize is the number of bytes read and checked at once.
+const defaultChunkSize = 4 * 1024 * 1024
+
+// Problem is an integrity problem found in a ycd file.
+type Problem struct {
+	File string
+	// Start and End are the byte range [Start, End) of the file affected.
+	// Both are 0 if the problem isn't about a range, e.g. a bad header.
+	Start, End int64
+	// FirstDigit and LastDigit are the range of digits after the decimal
+	// point [FirstDigit, LastDigit) affected if the problem is about digits.
+	FirstDigit, LastDigit int64
+	Err                   error
+}
+
+func (p *Problem) String() string {
+	var b strings.Builder
+	b.WriteString(p.File)
+	if p.End > p.Start {
+		fmt.Fprintf(&b, ": bytes [%d, %d)", p.Start, p.End)
+	}
+	if p.LastDigit > p.FirstDigit {
+		fmt.Fprintf(&b, ", digits [%d, %d)", p.FirstDigit, p.LastDigit)
+	}
+	fmt.Fprintf(&b, ": %v", p.Err)
+	return b.String()
+}
+
+// checker checks the files of a result set.
+type checker struct {
+	set    resultset.ResultSet
+	bucket obj.Bucket
+	// chunkSize is the number of bytes read at once, a multiple of the word size.
+	chunkSize int64
+	// words enables checking every word of the digits.
+	words bool
+	// spot enables spot checks of the padding of the last word of each block
+	// and, with checkFirstDigits, of the first digits in the headers. Blocks
+	// don't share any digits so adjacent blocks aren't compared with each other.
+	spot bool
+}
+
+// packedLen returns the number of bytes of packed digits in the block of f.
+// It differs from f.BlockByteLength, which the readers use to locate blocks,
+// for the last block of a set: it holds only the digits up to TotalDigits and
+// the file ends after the word holding the last one, so sizes based on
+// BlockSize would report every short last block as truncated.
+func packedLen(f *ycd.YCDFile) int64 {
+	dpw := int64(ycd.DigitsPerWord(f.Header.Radix))
+	return (f.Header.BlockDigits() + dpw - 1) / dpw * ycd.WordSize
+}
+
+// check checks the i-th file of the set and returns the problems found.
+func (c *checker) check(ctx context.Context, i int) []Problem {
+	f := c.set[i]
+	object := c.bucket.Object(f.Name)
+	if p := c.checkHeader(ctx, f, object); p != nil {
+		// The rest depends on the layout in the header.
+		return []Problem{*p}
+	}
+	var probs []Problem
+	if p := checkSize(ctx, f, object); p != nil {
+		probs = append(probs, *p)
+	}
+	if c.words {
+		probs = append(probs, c.checkWords(ctx, f, object)...)
+	}
+	if c.spot {
+		if p := c.checkPadding(ctx, f); p != nil {
+			probs = append(probs, *p)
+		}
+	}
+	return probs
+}
+
+// checkHeader parses the header of f and compares it with the index.
+func (c *checker) checkHeader(ctx context.Context, f *ycd.YCDFile, object obj.Object) *Problem {
+	rd, err := object.NewRangeReader(ctx, 0, ycd.MaxHeaderLength)
+	if err != nil {
+		return &Problem{File: f.Name, Err: fmt.Errorf("%w: %v", errRead, err)}
+	}
+	defer rd.Close()
+	parsed, err := ycd.Parse(rd)
+	if err != nil {
+		return &Problem{File: f.Name, Err: fmt.Errorf("%w: %v", errHeader, err)}
+	}
+	parsed.Name = f.Name
+	if !reflect.DeepEqual(f, parsed) {
+		return &Problem{File: f.Name, Err: fmt.Errorf("%w: got %+v at offset %d, want %+v at offset %d",
+			errHeader, *parsed.Header, parsed.FirstDigitOffset, *f.Header, f.FirstDigitOffset)}
+	}
+	return nil
+}
+
+// checkSize compares the size of object with the header of f if the object
+// reports its size.
+func checkSize(ctx context.Context, f *ycd.YCDFile, object obj.Object) *Problem {
+	sizer, ok := object.(obj.Sizer)
+	if !ok {
+		return nil
+	}
+	size, err := sizer.Size(ctx)
+	if errors.Is(err, obj.ErrNoSize) {
+		return nil
+	}
+	if err != nil {
+		return &Problem{File: f.Name, Err: fmt.Errorf("%w: %v", errRead, err)}
+	}
+	want := int64(f.FirstDigitOffset) + packedLen(f)
+	if size != want {
+		start, end := size, want
+		if size > want {
+			start, end = want, size
+		}
+		return &Problem{File: f.Name, Start: start, End: end,
+			Err: fmt.Errorf("%w: %d bytes, want %d", errSize, size, want)}
+	}
+	return nil
+}
+
+// digitProblem returns a Problem for the words in the byte range [start, end)
+// of the digits of f.
+func digitProblem(f *ycd.YCDFile, start, end int64, err error) Problem {
+	dpw := int64(ycd.DigitsPerWord(f.Header.Radix))
+	first := f.Header.BlockID * f.Header.BlockSize
+	last := first + (end+ycd.WordSize-1)/ycd.WordSize*dpw
+	if max := first + f.Header.BlockDigits(); last > max {
+		last = max
+	}
+	return Problem{
+		File:       f.Name,
+		Start:      int64(f.FirstDigitOffset) + start,
+		End:        int64(f.FirstDigitOffset) + end,
+		FirstDigit: first + start/ycd.WordSize*dpw,
+		LastDigit:  last,
+		Err:        err,
+	}
+}
+
+// checkWords reads the digits of f and reports runs of invalid words
+// and missing data.
+func (c *checker) checkWords(ctx context.Context, f *ycd.YCDFile, object obj.Object) []Problem {
+	length := packedLen(f)
+	var probs []Problem
+	// The current run of invalid words is [runStart, off) if runStart >= 0.
+	runStart := int64(-1)
+	endRun := func(off int64) {
+		if runStart >= 0 {
+			probs = append(probs, digitProblem(f, runStart, off,
+				fmt.Errorf("%w: %d words", errInvalidWords, (off-runStart)/ycd.WordSize)))
+			runStart = -1
+		}
+	}
+
+	buf := make([]byte, c.chunkSize)
+	off := int64(0)
+	for off < length {
+		n := length - off
+		if n > c.chunkSize {
+			n = c.chunkSize
+		}
+		read, err := readRange(ctx, object, int64(f.FirstDigitOffset)+off, buf[:n])
+		for i := 0; i+ycd.WordSize <= read; i += ycd.WordSize {
+			if !unpack.ValidWord(binary.LittleEndian.Uint64(buf[i:]), f.Header.Radix) {
+				if runStart < 0 {
+					runStart = off + int64(i)
+				}
+			} else {
+				endRun(off + int64(i))
+			}
+		}
+		// A partial word counts as missing.
+		off += int64(read) / ycd.WordSize * ycd.WordSize
+		if err != nil {
+			endRun(off)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				err = errTruncated
+			} else {
+				err = fmt.Errorf("%w: %v", errRead, err)
+			}
+			return append(probs, digitProblem(f, off, length, err))
+		}
+	}
+	endRun(off)
+	return probs
+}
+
+// readRange fills buf with the bytes of object at off.
+// It returns io.ErrUnexpectedEOF or io.EOF if the object ends before.
+func readRange(ctx context.Context, object obj.Object, off int64, buf []byte) (int, error) {
+	rd, err := object.NewRangeReader(ctx, off, int64(len(buf)))
+	if err != nil {
+		return 0, err
+	}
+	defer rd.Close()
+	return io.ReadFull(rd, buf)
+}
+
+// checkPadding checks that the unused digits of the last word of f are zeros
+// if the block ends in the middle of a word, e.g. the 5 digits after the
+// last 14 of every pi100t decimal block of 10^11 digits.
+//
+// Zero padding is what ycd.Writer writes and what the readers rely on
+// (unpack.UnpackBlock ignores the unused digits), but it hasn't been
+// confirmed against the files written by y-cruncher, so an errPadding problem
+// alone may be a false positive: compare the digits with another copy before
+// replacing the file.
+func (c *checker) checkPadding(ctx context.Context, f *ycd.YCDFile) *Problem {
+	dpw := int64(ycd.DigitsPerWord(f.Header.Radix))
+	used := f.Header.BlockDigits() % dpw
+	if used == 0 {
+		return nil
+	}
+	off := packedLen(f) - ycd.WordSize
+	buf := make([]byte, ycd.WordSize)
+	if _, err := readRange(ctx, c.bucket.Object(f.Name), int64(f.FirstDigitOffset)+off, buf); err != nil {
+		// Reported by checkWords.
+		if c.words {
+			return nil
+		}
+		p := digitProblem(f, off, off+ycd.WordSize, fmt.Errorf("%w: %v", errRead, err))
+		return &p
+	}
+	// The unused digits are the least significant ones.
+	unit := uint64(1)
+	for j := used; j < dpw; j++ {
+		unit *= uint64(f.Header.Radix)
+	}
+	if binary.LittleEndian.Uint64(buf)%unit != 0 {
+		p := digitProblem(f, off, off+ycd.WordSize, errPadding)
+		return &p
+	}
+	return nil
+}
+
+// checkFirstDigits compares the digits after the decimal point in the
+// FirstDigits header field of the index with the digits at the start of
+// block 0. checkHeader compares the header of every file with the index, so
+// this is done once per result set.
+func (c *checker) checkFirstDigits(ctx context.Context) *Problem {
+	block0 := c.set[0]
+	first := block0.Header.FirstDigits
+	dot := strings.IndexByte(first, '.')
+	if dot < 0 {
+		return nil
+	}
+	want := first[dot+1:]
+	if n := block0.Header.BlockDigits(); int64(len(want)) > n {
+		want = want[:n]
+	}
+	if want == "" {
+		return nil
+	}
+	dpw := ycd.DigitsPerWord(block0.Header.Radix)
+	words := (len(want) + dpw - 1) / dpw
+	buf := make([]byte, words*ycd.WordSize)
+	if _, err := readRange(ctx, c.bucket.Object(block0.Name), int64(block0.FirstDigitOffset), buf); err != nil {
+		return &Problem{File: block0.Name, Err: fmt.Errorf("%w: %v", errNoFirstDigits, err)}
+	}
+	got := make([]byte, len(want))
+	if _, err := unpack.UnpackBlock(got, buf, block0.Header.Radix, 0); err != nil {
+		return &Problem{File: block0.Name, LastDigit: int64(len(want)),
+			Err: fmt.Errorf("%w: %v", errFirstDigits, err)}
+	}
+	if string(got) != want {
+		return &Problem{File: block0.Name, LastDigit: int64(len(want)),
+			Err: fmt.Errorf("%w: header has %s", errFirstDigits, want)}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newChecker(t *testing.T, f *tests.Fixture, bucket obj.Bucket) *checker {
+	t.Helper()
+	return &checker{
+		set:       f.Set,
+		bucket:    bucket,
+		chunkSize: 32,
+		words:     true,
+		spot:      true,
+	}
+}
+
+// corrupt returns a copy of the file of block id with fn applied to its digits.
+func corrupt(f *tests.Fixture, id int, fn func(digits []byte) []byte) []byte {
+	file := f.Set[id]
+	data := append([]byte(nil), f.Files[file.Name]...)
+	return append(data[:file.FirstDigitOffset], fn(data[file.FirstDigitOffset:])...)
+}
+
+func TestCheck_Clean(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, fc := range []struct {
+		number    string
+		radix     int
+		blockSize int64
+	}{
+		{tests.PiDec, 10, 45},
+		{tests.PiDec, 10, 100},
+		{tests.PiHex, 16, 40},
+		{"3.14", 10, 45},
+	} {
+		f, err := tests.NewFixture(fc.number, fc.radix, fc.blockSize)
+		require.NoError(t, err)
+		c := newChecker(t, f, f.Bucket())
+		for i := range f.Set {
+			assert.Empty(t, c.check(ctx, i), "radix %d block %d", fc.radix, i)
+		}
+		assert.Nil(t, c.checkFirstDigits(ctx), "radix %d", fc.radix)
+	}
+}
+
+func TestCheck_Problems(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// Blocks of 45 digits in 3 words, the last one holding 7 digits,
+	// and a last block of 30 digits.
+	f, err := tests.NewFixture(tests.PiDec, 10, 45)
+	require.NoError(t, err)
+	first := int64(f.Set[0].FirstDigitOffset)
+
+	for _, tc := range []struct {
+		name  string
+		block int
+		data  []byte
+		// want are the problems in block.
+		want []Problem
+		// others is set if the other blocks have problems too.
+		others bool
+	}{
+		{
+			name:  "Invalid words",
+			block: 3,
+			data: corrupt(f, 3, func(d []byte) []byte {
+				copy(d, bytes.Repeat([]byte{0xff}, 16))
+				return d
+			}),
+			want: []Problem{{Start: first, End: first + 16, FirstDigit: 135, LastDigit: 135 + 38,
+				Err: errInvalidWords}},
+		},
+		{
+			name:  "Truncated",
+			block: 2,
+			data:  corrupt(f, 2, func(d []byte) []byte { return d[:12] }),
+			want: []Problem{
+				{Start: first + 12, End: first + 24, Err: errSize},
+				{Start: first + 8, End: first + 24, FirstDigit: 90 + 19, LastDigit: 135, Err: errTruncated},
+			},
+		},
+		{
+			name:  "Trailing bytes",
+			block: 6,
+			data:  corrupt(f, 6, func(d []byte) []byte { return append(d, 0) }),
+			want:  []Problem{{Start: first + 16, End: first + 17, Err: errSize}},
+		},
+		{
+			name:  "Wrong header",
+			block: 1,
+			data:  f.Files[f.Set[2].Name],
+			want:  []Problem{{Err: errHeader}},
+		},
+		{
+			name:  "Nonzero padding",
+			block: 4,
+			data: corrupt(f, 4, func(d []byte) []byte {
+				d[16]++
+				return d
+			}),
+			want: []Problem{{Start: first + 16, End: first + 24, FirstDigit: 180 + 38, LastDigit: 225,
+				Err: errPadding}},
+		},
+	} {
+		client := f.Client()
+		client.Put(tests.FixtureBucket, f.Set[tc.block].Name, tc.data)
+		c := newChecker(t, f, client.Bucket(tests.FixtureBucket))
+		for i := range f.Set {
+			probs := c.check(ctx, i)
+			if i != tc.block {
+				if tc.others {
+					assert.NotEmpty(t, probs, "%s: block %d", tc.name, i)
+				} else {
+					assert.Empty(t, probs, "%s: block %d", tc.name, i)
+				}
+				continue
+			}
+			if !assert.Len(t, probs, len(tc.want), "%s: %v", tc.name, probs) {
+				continue
+			}
+			for j, p := range probs {
+				assert.Equal(t, f.Set[i].Name, p.File, tc.name)
+				assert.ErrorIs(t, p.Err, tc.want[j].Err, tc.name)
+				p.File, p.Err = "", nil
+				want := tc.want[j]
+				want.Err = nil
+				assert.Equal(t, want, p, tc.name)
+			}
+		}
+	}
+}
+
+func TestCheck_FirstDigits(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f, err := tests.NewFixture(tests.PiDec, 10, 45)
+	require.NoError(t, err)
+	block0 := f.Set[0].Name
+
+	client := f.Client()
+	client.Put(tests.FixtureBucket, block0, corrupt(f, 0, func(d []byte) []byte {
+		d[0]++
+		return d
+	}))
+	c := newChecker(t, f, client.Bucket(tests.FixtureBucket))
+	p := c.checkFirstDigits(ctx)
+	if assert.NotNil(t, p) {
+		assert.ErrorIs(t, p.Err, errFirstDigits)
+		assert.Equal(t, block0, p.File)
+		assert.Equal(t, int64(45), p.LastDigit)
+	}
+	// The other blocks are fine on their own.
+	for i := 1; i < len(f.Set); i++ {
+		assert.Empty(t, c.check(ctx, i), "block %d", i)
+	}
+
+	client.Put(tests.FixtureBucket, block0, corrupt(f, 0, func(d []byte) []byte {
+		copy(d, bytes.Repeat([]byte{0xff}, 8))
+		return d
+	}))
+	p = c.checkFirstDigits(ctx)
+	if assert.NotNil(t, p) {
+		assert.ErrorIs(t, p.Err, errFirstDigits)
+		assert.Contains(t, p.Err.Error(), "invalid word")
+	}
+
+	client.Put(tests.FixtureBucket, block0, corrupt(f, 0, func(d []byte) []byte { return d[:4] }))
+	p = c.checkFirstDigits(ctx)
+	if assert.NotNil(t, p) {
+		assert.ErrorIs(t, p.Err, errNoFirstDigits)
+	}
+}
+
+func TestCheck_ShortLastBlock(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// The last block holds 30 digits in 2 words instead of 45 in 3.
+	f, err := tests.NewFixture(tests.PiDec, 10, 45)
+	require.NoError(t, err)
+	last := len(f.Set) - 1
+	file := f.Set[last]
+	require.Equal(t, int64(30), file.Header.BlockDigits())
+	assert.Equal(t, int64(16), packedLen(file))
+	assert.Equal(t, int64(24), file.BlockByteLength())
+	assert.Equal(t, int64(file.FirstDigitOffset)+packedLen(file), int64(len(f.Files[file.Name])))
+	assert.Empty(t, newChecker(t, f, f.Bucket()).check(ctx, last))
+
+	// A last block padded to BlockSize digits has 8 extra bytes.
+	client := f.Client()
+	client.Put(tests.FixtureBucket, file.Name, corrupt(f, last, func(d []byte) []byte {
+		return append(d, make([]byte, ycd.WordSize)...)
+	}))
+	probs := newChecker(t, f, client.Bucket(tests.FixtureBucket)).check(ctx, last)
+	if assert.Len(t, probs, 1) {
+		assert.ErrorIs(t, probs[0].Err, errSize)
+		first := int64(file.FirstDigitOffset)
+		assert.Equal(t, first+16, probs[0].Start)
+		assert.Equal(t, first+24, probs[0].End)
+	}
+}
+
+func TestCheck_NoSizer(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f, err := tests.NewFixture(tests.PiHex, 16, 40)
+	require.NoError(t, err)
+	client := f.Client()
+	client.Put(tests.FixtureBucket, f.Set[5].Name, corrupt(f, 5, func(d []byte) []byte { return d[:9] }))
+	// FaultyBucket objects don't implement obj.Sizer.
+	bucket := tests.NewFaultyBucket(client.Bucket(tests.FixtureBucket), func(int, string, int64, int64) tests.Fault {
+		return tests.Fault{}
+	})
+	_, ok := bucket.Object(f.Set[5].Name).(obj.Sizer)
+	require.False(t, ok)
+
+	logger = zap.NewNop().Sugar()
+	res := checkAll(ctx, newChecker(t, f, bucket), 1, len(f.Set), 3)
+	require.Len(t, res, len(f.Set)-1)
+	for i, probs := range res {
+		if i+1 != 5 {
+			assert.Empty(t, probs, "block %d", i+1)
+			continue
+		}
+		// The padding check can't read the last word either.
+		if assert.Len(t, probs, 1) {
+			assert.ErrorIs(t, probs[0].Err, errTruncated)
+			assert.Equal(t, int64(200+16), probs[0].FirstDigit)
+			assert.Equal(t, int64(240), probs[0].LastDigit)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := writeProblems(&buf, res)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, res[4][0].String()+"\n", buf.String())
+}
@@ -0,0 +1,146 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command ycd-check verifies the integrity of the ycd files of a result set,
+// e.g. a copy of gs://pi100t made with gsutil rsync, and prints one line per
+// corrupt range found. It exits with status 1 if any are found.
+//
+//	go run ./cmd/ycd-check -local /data -start 10 -n 5 -spot
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/objflags"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+	"go.uber.org/zap"
+)
+
+var logger *zap.SugaredLogger
+
+// checkAll checks the files of blocks [start, end) of c.set in parallel and
+// returns the problems found in each file, in block order.
+func checkAll(ctx context.Context, c *checker, start, end, workers int) [][]Problem {
+	res := make([][]Problem, end-start)
+	ch := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ch {
+				res[i-start] = c.check(ctx, i)
+				logger.Infow("checked", "file", c.set[i].Name, "problems", len(res[i-start]))
+			}
+		}()
+	}
+	for i := start; i < end; i++ {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+	return res
+}
+
+// writeProblems writes one line per problem and returns the number of problems.
+func writeProblems(w io.Writer, res [][]Problem) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	for _, probs := range res {
+		for _, p := range probs {
+			fmt.Fprintln(bw, p.String())
+			n++
+		}
+	}
+	return n, bw.Flush()
+}
+
+func main() {
+	l, _ := zap.NewDevelopment()
+	defer l.Sync()
+	zap.ReplaceGlobals(l)
+	logger = l.Sugar()
+
+	radix := flag.Int("radix", 10, "Radix of the result set to check: 10 or 16")
+	src := objflags.Register(flag.CommandLine, index.BucketName)
+	start := flag.Int("start", 0, "ID of the first block to check")
+	n := flag.Int("n", 0, "Number of blocks to check (0 checks up to the last block)")
+	words := flag.Bool("words", true, "Check that every word of the digits is a valid packed value")
+	spot := flag.Bool("spot", false, "Spot check the first digits in the headers and the zero padding of the last words")
+	workers := flag.Int("workers", 4, "Number of files to check in parallel")
+	chunkSize := flag.Int64("chunk", defaultChunkSize, "Number of bytes to read at once")
+	flag.Parse()
+
+	var set resultset.ResultSet
+	switch *radix {
+	case 10:
+		set = index.Decimal
+	case 16:
+		set = index.Hexadecimal
+	default:
+		logger.Errorf("unsupported radix: %d", *radix)
+		os.Exit(1)
+	}
+	if *start < 0 || *start >= len(set) || *n < 0 {
+		logger.Errorf("invalid block range: start %d, n %d, %d blocks", *start, *n, len(set))
+		os.Exit(1)
+	}
+	if *chunkSize < ycd.WordSize || *workers < 1 {
+		logger.Errorf("invalid chunk size or workers: %d, %d", *chunkSize, *workers)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	client, err := src.NewClient(ctx)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	end := len(set)
+	if *n > 0 && *start+*n < end {
+		end = *start + *n
+	}
+	c := &checker{
+		set:       set,
+		bucket:    client.Bucket(src.Bucket),
+		chunkSize: *chunkSize / ycd.WordSize * ycd.WordSize,
+		words:     *words,
+		spot:      *spot,
+	}
+	res := checkAll(ctx, c, *start, end, *workers)
+	if c.spot {
+		if p := c.checkFirstDigits(ctx); p != nil {
+			res = append([][]Problem{{*p}}, res...)
+		}
+	}
+	found, err := writeProblems(os.Stdout, res)
+	if err != nil {
+		logger.Errorf("couldn't write the problems: %v", err)
+		os.Exit(1)
+	}
+	logger.Infow("done", "files", end-*start, "problems", found)
+	if found > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,12 +1,13 @@
 module github.com/googlecloudplatform/pi-delivery
 
-go 1.17
+go 1.18
 
 require (
 	cloud.google.com/go/storage v1.21.0
 	github.com/GoogleCloudPlatform/functions-framework-go v1.5.3
 	github.com/goccy/go-json v0.9.5
 	github.com/golang/mock v1.6.0
+	github.com/sethvargo/go-retry v0.2.3
 	github.com/stretchr/testify v1.7.0
 	go.ajitem.com/zapdriver v1.4.0
 	go.uber.org/zap v1.21.0
@@ -29,7 +30,6 @@ require (
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/sethvargo/go-retry v0.2.3 // indirect
 	go.opencensus.io v0.23.0 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.8.0 // indirect
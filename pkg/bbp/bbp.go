@@ -0,0 +1,150 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bbp computes hexadecimal digits of pi at arbitrary positions with
+// the Bailey-Borwein-Plouffe formula
+//
+//	pi = sum_k 16^-k (4/(8k+1) - 2/(8k+4) - 1/(8k+5) - 1/(8k+6))
+//
+// without computing the digits before them. The time is linear in the position.
+package bbp
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// ErrAmbiguous is returned if the rounding error of the computation may
+// change the last digit.
+var ErrAmbiguous = errors.New("bbp: digits are ambiguous")
+
+// ErrOutOfRange is returned for positions below 0 or above MaxPosition.
+var ErrOutOfRange = errors.New("bbp: position out of range")
+
+// NumDigits is the number of digits returned by Digits.
+const NumDigits = 16
+
+// MaxPosition is the largest position accepted by Digits, far beyond what
+// can be computed in practice, for which the error bound fits in 64 bits.
+const MaxPosition = 1<<56 - 1
+
+// fixed is a number in [0, 1) in 128-bit fixed point. Arithmetic wraps
+// around, i.e. it is modulo 1.
+type fixed struct {
+	hi, lo uint64
+}
+
+func (a fixed) add(b fixed) fixed {
+	lo, carry := bits.Add64(a.lo, b.lo, 0)
+	hi, _ := bits.Add64(a.hi, b.hi, carry)
+	return fixed{hi, lo}
+}
+
+func (a fixed) sub(b fixed) fixed {
+	lo, borrow := bits.Sub64(a.lo, b.lo, 0)
+	hi, _ := bits.Sub64(a.hi, b.hi, borrow)
+	return fixed{hi, lo}
+}
+
+// shl returns a * 2^s modulo 1.
+func (a fixed) shl(s uint) fixed {
+	return fixed{a.hi<<s | a.lo>>(64-s), a.lo << s}
+}
+
+// shr returns a / 2^s for s < 128.
+func (a fixed) shr(s uint) fixed {
+	if s >= 64 {
+		return fixed{0, a.hi >> (s - 64)}
+	}
+	return fixed{a.hi >> s, a.lo>>s | a.hi<<(64-s)}
+}
+
+// frac returns r/m rounded down for r < m.
+func frac(r, m uint64) fixed {
+	hi, rem := bits.Div64(r, 0, m)
+	lo, _ := bits.Div64(rem, 0, m)
+	return fixed{hi, lo}
+}
+
+// powMod returns b^e mod m.
+func powMod(b, e, m uint64) uint64 {
+	if m == 1 {
+		return 0
+	}
+	r := uint64(1)
+	b %= m
+	if m <= 1<<32 {
+		// Products of residues fit in 64 bits.
+		for e > 0 {
+			if e&1 == 1 {
+				r = r * b % m
+			}
+			b = b * b % m
+			e >>= 1
+		}
+		return r
+	}
+	for e > 0 {
+		if e&1 == 1 {
+			hi, lo := bits.Mul64(r, b)
+			r = bits.Rem64(hi, lo, m)
+		}
+		hi, lo := bits.Mul64(b, b)
+		b = bits.Rem64(hi, lo, m)
+		e >>= 1
+	}
+	return r
+}
+
+// series returns the fractional part of sum_k 16^(n-k) / (8k+j).
+func series(n, j uint64) fixed {
+	var s fixed
+	for k := uint64(0); k <= n; k++ {
+		m := 8*k + j
+		s = s.add(frac(powMod(16, n-k, m), m))
+	}
+	// The terms after the n-th are 16^-t / m; stop when they vanish.
+	for t := uint(1); 4*t < 128; t++ {
+		m := 8*(n+uint64(t)) + j
+		s = s.add(frac(1, m).shr(4 * t))
+	}
+	return s
+}
+
+// Digits returns the NumDigits hexadecimal digits of pi starting at position
+// n after the hexadecimal point, e.g. "243f6a8885a308d3" for 0.
+// It returns ErrAmbiguous in the rare cases where the rounding errors may
+// carry into the last digit and ErrOutOfRange if n is negative or above
+// MaxPosition.
+func Digits(n int64) (string, error) {
+	if n < 0 || n > MaxPosition {
+		return "", fmt.Errorf("%w: %d", ErrOutOfRange, n)
+	}
+	un := uint64(n)
+	// frac(16^n pi) = 4 S1 - 2 S4 - S5 - S6 modulo 1.
+	x := series(un, 1).shl(2).
+		sub(series(un, 4).shl(1)).
+		sub(series(un, 5)).
+		sub(series(un, 6))
+
+	// Each of the n+32 terms of a series is off by less than 2^-127 and the
+	// series are multiplied by 8 in total, so the digits are known unless the
+	// low half is within the error of a carry.
+	bound := 16 * (un + 32)
+	if x.lo < bound || x.lo > ^uint64(0)-bound {
+		return "", fmt.Errorf("%w: position %d", ErrAmbiguous, n)
+	}
+	return fmt.Sprintf("%016x", x.hi), nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bbp
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBBP_PowMod(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		b, e := rnd.Uint64(), rnd.Uint64()%1000000
+		// Both the small and the large moduli paths.
+		for _, m := range []uint64{rnd.Uint64()%(1<<32) + 1, rnd.Uint64()>>4 + 1} {
+			want := new(big.Int).Exp(new(big.Int).SetUint64(b), new(big.Int).SetUint64(e), new(big.Int).SetUint64(m))
+			assert.Equal(t, want.Uint64(), powMod(b, e, m), "%d^%d mod %d", b, e, m)
+		}
+	}
+	assert.Equal(t, uint64(0), powMod(16, 5, 1))
+	assert.Equal(t, uint64(1), powMod(16, 0, 7))
+}
+
+func TestBBP_Digits(t *testing.T) {
+	t.Parallel()
+	digits := tests.PiHex[2:]
+	for n := 0; n+NumDigits <= len(digits); n++ {
+		got, err := Digits(int64(n))
+		require.NoError(t, err, "position %d", n)
+		assert.Equal(t, digits[n:n+NumDigits], got, "position %d", n)
+	}
+}
+
+func TestBBP_DigitsFar(t *testing.T) {
+	t.Parallel()
+	// From Bailey, Borwein and Plouffe, "On the Rapid Computation of Various
+	// Polylogarithmic Constants" (1997), starting at the millionth digit.
+	got, err := Digits(1000000 - 1)
+	require.NoError(t, err)
+	assert.Equal(t, "26c65e52cb4593", got[:14])
+}
+
+func TestBBP_InvalidPosition(t *testing.T) {
+	t.Parallel()
+	for _, n := range []int64{-1, MaxPosition + 1} {
+		_, err := Digits(n)
+		assert.ErrorIs(t, err, ErrOutOfRange, "position %d", n)
+	}
+}
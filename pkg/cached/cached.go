@@ -18,20 +18,82 @@ import (
 	"context"
 	"io"
 	"sync"
+	"sync/atomic"
 
 	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
 )
 
-const cacheSize = 1 * 1024 * 1024 // 1 MiB
-var radixes = map[int]int{10: 0, 16: 1}
+// DefaultCacheSize is the default number of packed bytes held by a Cache.
+const DefaultCacheSize = 1 * 1024 * 1024 // 1 MiB
 
-type cache struct {
-	once  sync.Once
+// Cache holds up to the first size bytes of packed digits of a result set.
+// A Cache must only be shared by readers of the same result set.
+type Cache struct {
+	// Accessed atomically; kept first for 64-bit alignment.
+	hits   int64
+	misses int64
+
+	set   resultset.ResultSet
 	lock  sync.RWMutex
 	cache []byte
 }
 
-var _cache = make([]cache, len(radixes))
+// Stats are the counters of a Cache.
+type Stats struct {
+	Hits   int64
+	Misses int64
+	// Bytes is the number of bytes currently cached.
+	Bytes int
+	// Capacity is the maximum number of bytes the cache can hold.
+	Capacity int
+}
+
+// NewCache returns a new empty Cache for set holding up to size bytes.
+func NewCache(set resultset.ResultSet, size int) *Cache {
+	return &Cache{
+		set:   set,
+		cache: make([]byte, 0, size),
+	}
+}
+
+// ResultSet returns the result set the cache was created for.
+func (c *Cache) ResultSet() resultset.ResultSet {
+	return c.set
+}
+
+// Reset drops the cached bytes and clears the counters.
+func (c *Cache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cache = c.cache[:0]
+	atomic.StoreInt64(&c.hits, 0)
+	atomic.StoreInt64(&c.misses, 0)
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Cache) Stats() Stats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return Stats{
+		Hits:     atomic.LoadInt64(&c.hits),
+		Misses:   atomic.LoadInt64(&c.misses),
+		Bytes:    len(c.cache),
+		Capacity: cap(c.cache),
+	}
+}
+
+// matches reports whether set is the result set the cache was created for.
+func (c *Cache) matches(set resultset.ResultSet) bool {
+	if len(c.set) != len(set) {
+		return false
+	}
+	if len(set) == 0 {
+		return true
+	}
+	return c.set.Radix() == set.Radix() &&
+		c.set[0].Name == set[0].Name &&
+		c.set[len(set)-1].Name == set[len(set)-1].Name
+}
 
 // UpstreamReader is the reader CachedReader reads from.
 type UpstreamReader interface {
@@ -41,25 +103,24 @@ type UpstreamReader interface {
 	ResultSet() resultset.ResultSet
 }
 
-// CachedReader provides a cache support for up to the first cacheSize bytes
+// CachedReader provides a cache support for the first bytes of a result set
 // on top of the UpstreamReader.
 type CachedReader struct {
 	off   int64
 	rd    UpstreamReader
 	ctx   context.Context
-	cache *cache
+	cache *Cache
 }
 
 var _ io.ReadSeeker = new(CachedReader)
 var _ io.ReaderAt = new(CachedReader)
 
-// NewCachedReader returns a new CachedReader for upstream rd.
-func NewCachedReader(ctx context.Context, rd UpstreamReader) *CachedReader {
-	cache := &_cache[radixes[rd.ResultSet().Radix()]]
-
-	cache.once.Do(func() {
-		cache.cache = make([]byte, 0, cacheSize)
-	})
+// NewCachedReader returns a new CachedReader for upstream rd using cache.
+// It panics if cache was created for a different result set than rd's.
+func NewCachedReader(ctx context.Context, cache *Cache, rd UpstreamReader) *CachedReader {
+	if !cache.matches(rd.ResultSet()) {
+		panic("NewCachedReader: cache belongs to a different result set")
+	}
 
 	return &CachedReader{
 		ctx:   ctx,
@@ -106,8 +167,10 @@ func (r *CachedReader) readCache(p []byte, offset int64) (int, bool) {
 	r.cache.lock.RLock()
 	defer r.cache.lock.RUnlock()
 	if int64(len(r.cache.cache)) <= offset {
+		atomic.AddInt64(&r.cache.misses, 1)
 		return 0, false
 	}
+	atomic.AddInt64(&r.cache.hits, 1)
 	return copy(p, r.cache.cache[offset:]), true
 }
 
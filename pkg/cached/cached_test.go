@@ -15,14 +15,12 @@
 package cached
 
 import (
-	"bytes"
 	"context"
-	"io"
+	"errors"
 	"testing"
 	"testing/iotest"
 
-	"github.com/golang/mock/gomock"
-	mock_obj "github.com/googlecloudplatform/pi-delivery/pkg/obj/mocks"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
 	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
 	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
 	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
@@ -40,7 +38,6 @@ func genTestByteSeq(n int) []byte {
 
 func TestCachedReader_Simple(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 	testSet := resultset.ResultSet{
 		{
 			Header: &ycd.Header{
@@ -57,13 +54,14 @@ func TestCachedReader_Simple(t *testing.T) {
 	ctx := context.Background()
 	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
 
-	bucket := mock_obj.NewMockBucket(mockCtrl)
-	object := mock_obj.NewMockObject(mockCtrl)
+	client := memory.NewClient()
+	tests.PutResultSet(client, "bucket", testSet, testBuf)
 
-	rr := testSet.NewReader(ctx, bucket)
+	rr := testSet.NewReader(ctx, client.Bucket("bucket"))
 	require.NotNil(t, rr)
 
-	reader := NewCachedReader(ctx, rr)
+	cache := NewCache(testSet, DefaultCacheSize)
+	reader := NewCachedReader(ctx, cache, rr)
 	require.NotNil(t, reader)
 
 	assert.Equal(t, testSet, reader.ResultSet())
@@ -77,49 +75,20 @@ func TestCachedReader_Simple(t *testing.T) {
 	}
 
 	// Check if the cache is working around boundaries.
-	bucket.EXPECT().
-		Object(testSet[0].Name).
-		Return(object).
-		MaxTimes(4)
-
-	gomock.InOrder(
-		object.EXPECT().
-			NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset), int64(10)).
-			Return(io.NopCloser(bytes.NewReader(testBuf)), nil).
-			MaxTimes(1),
-		object.EXPECT().
-			NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset)+20, int64(10)).
-			Return(io.NopCloser(bytes.NewReader(testBuf[20:])), nil).
-			MaxTimes(1),
-		object.EXPECT().
-			NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset)+10, int64(10)).
-			Return(io.NopCloser(bytes.NewReader(testBuf[10:])), nil).
-			MaxTimes(1),
-		object.EXPECT().
-			NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset)+20, int64(10)).
-			Return(io.NopCloser(bytes.NewReader(testBuf[20:])), nil).
-			MaxTimes(1),
-	)
-
+	// Only contiguous reads from the start are cached.
 	test(0, 10)
 	test(20, 10)
 	test(10, 10)
 	test(20, 10)
+	assert.Equal(t, Stats{Misses: 4, Bytes: 30, Capacity: DefaultCacheSize}, cache.Stats())
+
+	// Cached bytes don't need upstream reads.
+	client.SetFaults(memory.Faults{Err: errors.New("upstream read")})
 	test(0, 30)
+	assert.Equal(t, int64(1), cache.Stats().Hits)
+	client.SetFaults(memory.Faults{})
 
 	// Make sure the cache is correctly constructed.
-	bucket.EXPECT().Object(testSet[0].Name).Return(object).AnyTimes()
-
-	object.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 0, testBuf, off, length)
-		},
-	).AnyTimes()
-
 	test(15, 30)
 	test(25, 10)
 	test(11, 2)
@@ -132,7 +101,6 @@ func TestCachedReader_Simple(t *testing.T) {
 
 func TestCachedReader_IOTestSmall(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
 	testSet := resultset.ResultSet{
 		{
@@ -161,38 +129,14 @@ func TestCachedReader_IOTestSmall(t *testing.T) {
 	ctx := context.Background()
 	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
 
-	bucket := mock_obj.NewMockBucket(mockCtrl)
-	obj0 := mock_obj.NewMockObject(mockCtrl)
-	obj1 := mock_obj.NewMockObject(mockCtrl)
-
-	bucket.EXPECT().Object(testSet[0].Name).Return(obj0).AnyTimes()
-	bucket.EXPECT().Object(testSet[1].Name).Return(obj1).AnyTimes()
-
-	obj0.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 0, testBuf, off, length)
-		},
-	).AnyTimes()
-
-	obj1.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 1, testBuf, off, length)
-		},
-	).AnyTimes()
+	client := memory.NewClient()
+	tests.PutResultSet(client, "bucket", testSet, testBuf)
 
-	rr := testSet.NewReader(ctx, bucket)
+	rr := testSet.NewReader(ctx, client.Bucket("bucket"))
 	require.NotNil(t, rr)
 	defer assert.NoError(t, rr.Close())
 
-	reader := NewCachedReader(ctx, rr)
+	reader := NewCachedReader(ctx, NewCache(testSet, DefaultCacheSize), rr)
 	require.NotNil(t, reader)
 
 	assert.Equal(t, testSet, reader.ResultSet())
@@ -202,7 +146,6 @@ func TestCachedReader_IOTestSmall(t *testing.T) {
 
 func TestCachedReader_IOTestLarge(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
 	testSet := resultset.ResultSet{
 		{
@@ -235,41 +178,84 @@ func TestCachedReader_IOTestLarge(t *testing.T) {
 	ctx := context.Background()
 	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
 
-	bucket := mock_obj.NewMockBucket(mockCtrl)
-	obj0 := mock_obj.NewMockObject(mockCtrl)
-	obj1 := mock_obj.NewMockObject(mockCtrl)
+	client := memory.NewClient()
+	tests.PutResultSet(client, "bucket", testSet, testBuf)
 
-	bucket.EXPECT().Object(testSet[0].Name).Return(obj0).AnyTimes()
-	bucket.EXPECT().Object(testSet[1].Name).Return(obj1).AnyTimes()
-
-	obj0.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 0, testBuf, off, length)
-		},
-	).AnyTimes()
-
-	obj1.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 1, testBuf, off, length)
-		},
-	).AnyTimes()
-
-	rr := testSet.NewReader(ctx, bucket)
+	rr := testSet.NewReader(ctx, client.Bucket("bucket"))
 	require.NotNil(t, rr)
 	defer assert.NoError(t, rr.Close())
 
-	reader := NewCachedReader(ctx, rr)
+	reader := NewCachedReader(ctx, NewCache(testSet, DefaultCacheSize), rr)
 	require.NotNil(t, reader)
 
 	assert.Equal(t, testSet, reader.ResultSet())
 
 	assert.NoError(t, iotest.TestReader(reader, testBuf))
 }
+
+func TestCachedReader_PerResultSet(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	newSet := func(name string) resultset.ResultSet {
+		return resultset.ResultSet{
+			{
+				Header: &ycd.Header{
+					Radix:       10,
+					TotalDigits: int64(0),
+					BlockSize:   int64(100),
+					BlockID:     int64(0),
+					Length:      198,
+				},
+				Name:             name,
+				FirstDigitOffset: 201,
+			},
+		}
+	}
+	piSet := newSet("Pi - Dec - Chudnovsky/Pi - Dec - Chudnovsky - 0.ycd")
+	eSet := newSet("e - Dec - exp(1)/e - Dec - exp(1) - 0.ycd")
+
+	piBuf := genTestByteSeq(int(piSet.TotalByteLength()))
+	eBuf := make([]byte, len(piBuf))
+	for i := range eBuf {
+		eBuf[i] = ^piBuf[i]
+	}
+
+	newReader := func(set resultset.ResultSet, buf []byte) *resultset.Reader {
+		client := memory.NewClient()
+		tests.PutResultSet(client, "bucket", set, buf)
+		return set.NewReader(ctx, client.Bucket("bucket"))
+	}
+
+	piCache := NewCache(piSet, DefaultCacheSize)
+	eCache := NewCache(eSet, DefaultCacheSize)
+	assert.Equal(t, piSet, piCache.ResultSet())
+
+	piReader := NewCachedReader(ctx, piCache, newReader(piSet, piBuf))
+	eReader := NewCachedReader(ctx, eCache, newReader(eSet, eBuf))
+
+	buf := make([]byte, 16)
+	for i := 0; i < 2; i++ {
+		n, err := piReader.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, piBuf[:n], buf[:n])
+
+		n, err = eReader.ReadAt(buf, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, eBuf[:n], buf[:n])
+	}
+
+	st := piCache.Stats()
+	assert.Equal(t, int64(1), st.Hits)
+	assert.Equal(t, int64(1), st.Misses)
+	assert.Equal(t, 16, st.Bytes)
+	assert.Equal(t, DefaultCacheSize, st.Capacity)
+
+	piCache.Reset()
+	assert.Equal(t, Stats{Capacity: DefaultCacheSize}, piCache.Stats())
+	assert.Equal(t, 16, eCache.Stats().Bytes)
+
+	assert.Panics(t, func() {
+		NewCachedReader(ctx, piCache, newReader(eSet, eBuf))
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package detect implements detectors of digit patterns run over chunks of
+// unpacked digits.
+package detect
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidParameter is returned by Detect for invalid detector parameters.
+var ErrInvalidParameter = errors.New("detect: invalid parameter")
+
+// Chunk is a section of unpacked digits handed to detectors.
+// Chunks processed in parallel overlap: each chunk owns the digits in
+// Digits[Lo:Hi] and the digits around them are context shared with the
+// neighboring chunks. Detectors only report matches anchored in the owned
+// digits so every match is reported exactly once.
+type Chunk struct {
+	// Start is the digit offset of Digits[0]
+	// (0 is the first digit after the decimal point).
+	Start int64
+	// Digits are the unpacked digits ("14159...").
+	Digits []byte
+	// Lo and Hi delimit the digits owned by the chunk.
+	Lo, Hi int
+	// Radix is the base of the digits.
+	Radix int
+	// IntegerDigits is the number of digits before the point, i.e.
+	// len(ResultSet.IntegerPart()), copied to the matches.
+	IntegerDigits int
+	// Source is used to read digits past the end of Digits, e.g. for a match
+	// longer than the context. It is typically an unpack.UnpackReader.
+	// Nil if there are no more digits.
+	Source io.ReaderAt
+}
+
+// position returns the position of c.Digits[i] numbered like Match.Position.
+func (c *Chunk) position(i int) int64 {
+	return c.Start + int64(i) + int64(c.IntegerDigits)
+}
+
+// Extend appends up to n digits following Digits read from Source.
+// It returns the number of digits appended, which is 0 at the end of the digits.
+func (c *Chunk) Extend(n int) (int, error) {
+	if c.Source == nil || n <= 0 {
+		return 0, nil
+	}
+	buf := make([]byte, n)
+	read, err := c.Source.ReadAt(buf, c.Start+int64(len(c.Digits)))
+	c.Digits = append(c.Digits, buf[:read]...)
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return read, err
+}
+
+// Match is a finding of a detector.
+type Match struct {
+	// Detector is the name of the detector.
+	Detector string
+	// Pos is the digit offset of the first digit of the match.
+	Pos int64
+	// Length is the number of digits in the match.
+	Length int
+	// Digits are the digits of the match.
+	Digits string
+	// Note is optional detector specific information, e.g. "prime".
+	Note string
+	// IntegerDigits is Chunk.IntegerDigits of the chunk the match is in.
+	IntegerDigits int
+}
+
+// Position returns the position of the match as numbered by service.Get
+// and api.pi.delivery (the first digit before the decimal point is 0).
+func (m *Match) Position() int64 {
+	return m.Pos + int64(m.IntegerDigits)
+}
+
+// Detector finds patterns in chunks.
+// Implementations must be safe for concurrent use by multiple goroutines.
+type Detector interface {
+	// Name returns the name of the detector, e.g. "palindrome".
+	Name() string
+	// Detect calls emit for every match anchored in the owned digits of c.
+	Detect(c *Chunk, emit func(Match)) error
+}
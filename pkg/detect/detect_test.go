@@ -0,0 +1,459 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// piDigits are the first 800 digits of pi after the decimal point.
+const piDigits = "14159265358979323846264338327950288419716939937510" +
+	"58209749445923078164062862089986280348253421170679" +
+	"82148086513282306647093844609550582231725359408128" +
+	"48111745028410270193852110555964462294895493038196" +
+	"44288109756659334461284756482337867831652712019091" +
+	"45648566923460348610454326648213393607260249141273" +
+	"72458700660631558817488152092096282925409171536436" +
+	"78925903600113305305488204665213841469519415116094" +
+	"33057270365759591953092186117381932611793105118548" +
+	"07446237996274956735188575272489122793818301194912" +
+	"98336733624406566430860213949463952247371907021798" +
+	"60943702770539217176293176752384674818467669405132" +
+	"00056812714526356082778577134275778960917363717872" +
+	"14684409012249534301465495853710507922796892589235" +
+	"42019956112129021960864034418159813629774771309960" +
+	"51870721134999999837297804995105973173281609631859"
+
+// scan runs d over digits split into chunks owning size digits each, with
+// context digits of context on each side, like pi-processor does.
+func scan(t *testing.T, d Detector, digits string, size, context int) []Match {
+	t.Helper()
+	var res []Match
+	for lo := 0; lo < len(digits); lo += size {
+		start := lo - context
+		if start < 0 {
+			start = 0
+		}
+		hi := lo + size
+		if hi > len(digits) {
+			hi = len(digits)
+		}
+		end := hi + context
+		if end > len(digits) {
+			end = len(digits)
+		}
+		c := &Chunk{
+			Start:  int64(start),
+			Digits: []byte(digits[start:end]),
+			Lo:     lo - start,
+			Hi:     hi - start,
+			Radix:  10,
+			Source: strings.NewReader(digits),
+			// The digits after the point of pi.
+			IntegerDigits: 1,
+		}
+		require.NoError(t, d.Detect(c, func(m Match) { res = append(res, m) }))
+	}
+	return res
+}
+
+func TestDetect_Extend(t *testing.T) {
+	t.Parallel()
+	c := &Chunk{Digits: []byte("1415"), Source: strings.NewReader(piDigits[:6])}
+	n, err := c.Extend(10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, "141592", string(c.Digits))
+	n, err = c.Extend(10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	c = &Chunk{Digits: []byte("1415")}
+	n, err = c.Extend(10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestDetect_Runs(t *testing.T) {
+	t.Parallel()
+	d := &Runs{MinLength: 6}
+	expected := []Match{{Detector: "run", Pos: 761, Length: 6, Digits: "999999", IntegerDigits: 1}}
+	assert.Equal(t, expected, scan(t, d, piDigits, len(piDigits), 0))
+	assert.Equal(t, int64(762), expected[0].Position())
+
+	// Chunk boundaries in and around the Feynman point.
+	for size := 1; size < 20; size++ {
+		for _, context := range []int{1, 3, 100} {
+			assert.Equal(t, expected, scan(t, d, piDigits, size, context), "size %d context %d", size, context)
+		}
+	}
+}
+
+func TestDetect_RunsLongerThanContext(t *testing.T) {
+	t.Parallel()
+	digits := "12" + strings.Repeat("7", 10000) + "3" + strings.Repeat("7", 5) + "44"
+	expected := []Match{
+		{Detector: "run", Pos: 2, Length: 10000, Digits: strings.Repeat("7", 10000), IntegerDigits: 1},
+		{Detector: "run", Pos: 10003, Length: 5, Digits: "77777", IntegerDigits: 1},
+	}
+	for _, size := range []int{1, 7, 1000, len(digits)} {
+		res := scan(t, &Runs{MinLength: 5}, digits, size, 1)
+		assert.Equal(t, expected, res, "size %d", size)
+	}
+
+	// Every digit belongs to exactly one run.
+	res := scan(t, &Runs{MinLength: 1}, digits, 3, 1)
+	assert.Len(t, res, 6)
+	total := 0
+	for _, m := range res {
+		total += m.Length
+	}
+	assert.Equal(t, len(digits), total)
+}
+
+// naivePalindromes returns the odd length palindromes in digits that are at
+// least minLength long.
+func naivePalindromes(digits string, minLength int) []Match {
+	var res []Match
+	for c := range digits {
+		r := 0
+		for c-r-1 >= 0 && c+r+1 < len(digits) && digits[c-r-1] == digits[c+r+1] {
+			r++
+		}
+		if 2*r+1 >= minLength {
+			res = append(res, Match{
+				Detector:      "palindrome",
+				Pos:           int64(c - r),
+				Length:        2*r + 1,
+				Digits:        digits[c-r : c+r+1],
+				IntegerDigits: 1,
+			})
+		}
+	}
+	return res
+}
+
+func TestDetect_Palindromes(t *testing.T) {
+	t.Parallel()
+	d := &Palindromes{MinLength: 5}
+	expected := naivePalindromes(piDigits, 5)
+	require.NotEmpty(t, expected)
+	assert.Equal(t, Match{Detector: "palindrome", Pos: 18, Length: 5, Digits: "46264", IntegerDigits: 1}, expected[0])
+
+	for _, size := range []int{1, 2, 7, 50, len(piDigits)} {
+		t.Run(fmt.Sprint(size), func(t *testing.T) {
+			assert.Equal(t, expected, scan(t, d, piDigits, size, 10))
+		})
+	}
+}
+
+func TestDetect_PalindromesPrime(t *testing.T) {
+	t.Parallel()
+	res := scan(t, &Palindromes{MinLength: 5, Prime: PrimeTag}, "5"+"10301"+"6"+"12321"+"7", 100, 0)
+	assert.Equal(t, []Match{
+		{Detector: "palindrome", Pos: 1, Length: 5, Digits: "10301", Note: "prime", IntegerDigits: 1},
+		{Detector: "palindrome", Pos: 7, Length: 5, Digits: "12321", Note: "composite", IntegerDigits: 1},
+	}, res)
+
+	res = scan(t, &Palindromes{MinLength: 7, Prime: PrimeFilter}, "9"+"1003001"+"8"+"1234321"+"5", 100, 0)
+	require.Len(t, res, 1)
+	assert.Equal(t, "1003001", res[0].Digits)
+	assert.Equal(t, int64(1), res[0].Pos)
+}
+
+func TestDetect_Increment(t *testing.T) {
+	t.Parallel()
+	for radix, n := range map[int]int{10: 12345, 16: 70000, 2: 300} {
+		repr := []byte("1")
+		for i := int64(1); i < int64(n); i++ {
+			require.Equal(t, strconv.FormatInt(i, radix), string(repr))
+			repr = increment(repr, radix)
+		}
+	}
+}
+
+func TestDetect_SelfLocating(t *testing.T) {
+	t.Parallel()
+	d := &SelfLocating{}
+	expected := []Match{{Detector: "self-locating", Pos: 0, Length: 1, Digits: "1", IntegerDigits: 1}}
+	assert.Equal(t, expected, scan(t, d, piDigits, len(piDigits), 0))
+
+	digits := []byte(strings.Repeat("0", 20000))
+	for pos, s := range map[int]string{9: "9", 10: "10", 99: "99", 16470: "16470", 19999: "19999"} {
+		copy(digits[pos-1:], s)
+	}
+	expected = []Match{
+		{Detector: "self-locating", Pos: 8, Length: 1, Digits: "9", IntegerDigits: 1},
+		{Detector: "self-locating", Pos: 9, Length: 2, Digits: "10", IntegerDigits: 1},
+		{Detector: "self-locating", Pos: 98, Length: 2, Digits: "99", IntegerDigits: 1},
+		{Detector: "self-locating", Pos: 16469, Length: 5, Digits: "16470", IntegerDigits: 1},
+	}
+	for _, size := range []int{1, 3, 1000, len(digits)} {
+		res := scan(t, d, string(digits), size, 1)
+		assert.Equal(t, expected, res, "size %d", size)
+		assert.Equal(t, int64(16470), res[3].Position())
+	}
+
+	hex := &Chunk{Digits: []byte("0000000000000000000000000000001f00"), Hi: 34, Radix: 16, IntegerDigits: 1}
+	var res []Match
+	require.NoError(t, d.Detect(hex, func(m Match) { res = append(res, m) }))
+	assert.Equal(t, []Match{{Detector: "self-locating", Pos: 30, Length: 2, Digits: "1f", IntegerDigits: 1}}, res)
+
+	// Two digits before the point like sqrt(200) = 14.142...
+	// The first digit after the point is at position 2.
+	c := &Chunk{Digits: []byte("0000000010000"), Hi: 13, Radix: 10, IntegerDigits: 2}
+	res = nil
+	require.NoError(t, d.Detect(c, func(m Match) { res = append(res, m) }))
+	if assert.Equal(t, []Match{{Detector: "self-locating", Pos: 8, Length: 2, Digits: "10", IntegerDigits: 2}}, res) {
+		assert.Equal(t, int64(10), res[0].Position())
+	}
+}
+
+func TestDetect_PalindromesHexadecimal(t *testing.T) {
+	t.Parallel()
+	d := &Palindromes{MinLength: 3, Prime: PrimeTag}
+	c := &Chunk{Digits: []byte("a1b1c" + "2e2" + "f0f"), Hi: 11, Radix: 16, IntegerDigits: 1}
+	var res []Match
+	require.NoError(t, d.Detect(c, func(m Match) { res = append(res, m) }))
+	// 0x1b1 = 433 and 0x2e2 = 738.
+	assert.Equal(t, []Match{
+		{Detector: "palindrome", Pos: 1, Length: 3, Digits: "1b1", Note: "prime", IntegerDigits: 1},
+		{Detector: "palindrome", Pos: 5, Length: 3, Digits: "2e2", Note: "composite", IntegerDigits: 1},
+		{Detector: "palindrome", Pos: 8, Length: 3, Digits: "f0f", Note: "composite", IntegerDigits: 1},
+	}, res)
+}
+
+// naiveKPalindromes returns the longest palindrome with at most k
+// mismatched pairs and matching outermost digits for every center.
+func naiveKPalindromes(digits string, k, minLength int) []Match {
+	var res []Match
+	for i := range digits {
+		for _, even := range []bool{false, true} {
+			best := ""
+			bestPos := 0
+			var bestMismatches []int
+			for lo := i; lo >= 0; lo-- {
+				hi := 2*i - lo
+				if even {
+					hi--
+				}
+				if hi >= len(digits) {
+					break
+				}
+				if hi < lo || digits[lo] != digits[hi] {
+					continue
+				}
+				var mismatches []int
+				for l := lo; l < i; l++ {
+					if digits[l] != digits[lo+hi-l] {
+						mismatches = append([]int{l}, mismatches...)
+					}
+				}
+				if len(mismatches) <= k {
+					best, bestPos, bestMismatches = digits[lo:hi+1], lo, mismatches
+				}
+			}
+			if even && best == "" || len(best) < minLength {
+				continue
+			}
+			m := Match{Detector: "k-palindrome", Pos: int64(bestPos), Length: len(best), Digits: best, IntegerDigits: 1}
+			if len(bestMismatches) > 0 {
+				m.Note = "mismatches"
+				for _, l := range bestMismatches {
+					m.Note += fmt.Sprintf(" %d/%d", l+1, 2*bestPos+len(best)-l)
+				}
+			}
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+func TestDetect_KMismatchPalindromes(t *testing.T) {
+	t.Parallel()
+	d := &KMismatchPalindromes{K: 1, MinLength: 9}
+	res := scan(t, d, "5"+"123406321"+"7", 100, 0)
+	assert.Equal(t, []Match{{Detector: "k-palindrome", Pos: 1, Length: 9, Digits: "123406321", Note: "mismatches 5/7", IntegerDigits: 1}}, res)
+	assert.Equal(t, int64(2), res[0].Position())
+
+	res = scan(t, &KMismatchPalindromes{K: 2, MinLength: 8}, "9"+"12344331"+"8", 100, 0)
+	assert.Equal(t, []Match{{Detector: "k-palindrome", Pos: 1, Length: 8, Digits: "12344331", Note: "mismatches 3/8", IntegerDigits: 1}}, res)
+	res = scan(t, &KMismatchPalindromes{K: 2, MinLength: 9}, "9"+"123454981"+"7", 100, 0)
+	assert.Equal(t, []Match{{Detector: "k-palindrome", Pos: 1, Length: 9, Digits: "123454981", Note: "mismatches 4/8 3/9", IntegerDigits: 1}}, res)
+
+	for _, k := range []int{0, 1, 2, 3} {
+		minLength := 3 + 2*k
+		digits := piDigits[:400]
+		expected := naiveKPalindromes(digits, k, minLength)
+		require.NotEmpty(t, expected)
+		for _, size := range []int{1, 13, len(digits)} {
+			res := scan(t, &KMismatchPalindromes{K: k, MinLength: minLength}, digits, size, 50)
+			assert.Equal(t, expected, res, "k %d size %d", k, size)
+		}
+	}
+}
+
+func TestDetect_KMismatchPalindromesInvalid(t *testing.T) {
+	t.Parallel()
+	c := &Chunk{Digits: []byte("12321"), Hi: 5, Radix: 10}
+	for _, d := range []*KMismatchPalindromes{
+		{K: -1, MinLength: 5},
+		{K: 1, MinLength: 0},
+		{K: 1, MinLength: -5},
+	} {
+		err := d.Detect(c, func(Match) { t.Error("unexpected match") })
+		assert.ErrorIs(t, err, ErrInvalidParameter, "K %d MinLength %d", d.K, d.MinLength)
+	}
+}
+
+func BenchmarkKMismatchPalindromes(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	digits := make([]byte, 1_000_000)
+	for i := range digits {
+		digits[i] = byte('0' + rnd.Intn(10))
+	}
+	d := &KMismatchPalindromes{K: 3, MinLength: 25}
+	b.SetBytes(int64(len(digits)))
+	for i := 0; i < b.N; i++ {
+		c := &Chunk{Digits: digits, Hi: len(digits), Radix: 10}
+		d.Detect(c, func(Match) {})
+	}
+}
+
+func TestDetect_Progressions(t *testing.T) {
+	t.Parallel()
+	digits := "5" + "0123456789" + "8" + "9876543" + "1" + "890123" + "3" + "2468" + "1"
+	expected := []Match{
+		{Detector: "progression", Pos: 1, Length: 10, Digits: "0123456789", Note: "step +1", IntegerDigits: 1},
+		{Detector: "progression", Pos: 12, Length: 7, Digits: "9876543", Note: "step -1", IntegerDigits: 1},
+		{Detector: "progression", Pos: 20, Length: 6, Digits: "890123", Note: "step +1", IntegerDigits: 1},
+	}
+	for _, size := range []int{1, 4, len(digits)} {
+		assert.Equal(t, expected, scan(t, &Progressions{MinLength: 5}, digits, size, 1), "size %d", size)
+	}
+	res := scan(t, &Progressions{MinLength: 4}, digits, len(digits), 0)
+	assert.Equal(t, Match{Detector: "progression", Pos: 27, Length: 4, Digits: "2468", Note: "step +2", IntegerDigits: 1}, res[3])
+
+	// A progression longer than the chunk and its context.
+	long := "5" + strings.Repeat("0369258147", 100) + "5"
+	res = scan(t, &Progressions{MinLength: 20}, long, 9, 1)
+	assert.Equal(t, []Match{{Detector: "progression", Pos: 1, Length: 1000, Digits: long[1:1001], Note: "step +3", IntegerDigits: 1}}, res)
+
+	hex := &Chunk{Digits: []byte("0fedcba98"), Hi: 9, Radix: 16, IntegerDigits: 1}
+	res = nil
+	require.NoError(t, (&Progressions{MinLength: 3}).Detect(hex, func(m Match) { res = append(res, m) }))
+	assert.Equal(t, []Match{{Detector: "progression", Pos: 0, Length: 9, Digits: "0fedcba98", Note: "step -1", IntegerDigits: 1}}, res)
+
+	// No progressions of step 0.
+	assert.Empty(t, scan(t, &Progressions{MinLength: 2}, "7777777", 3, 1))
+}
+
+// naiveTandemRepeats returns the runs with a primitive root of at most
+// maxPeriod digits, sorted by position and period.
+func naiveTandemRepeats(digits string, maxPeriod, minLength int) []Match {
+	var res []Match
+	for p := 1; p <= maxPeriod; p++ {
+		for i := 0; i+p < len(digits); {
+			if digits[i] != digits[i+p] {
+				i++
+				continue
+			}
+			j := i
+			for j+p < len(digits) && digits[j] == digits[j+p] {
+				j++
+			}
+			length := j - i + p
+			if length >= 2*p && length >= minLength && primitive([]byte(digits[i:i+p])) {
+				res = append(res, Match{
+					Detector:      "tandem",
+					Pos:           int64(i),
+					Length:        length,
+					Digits:        digits[i : i+length],
+					Note:          fmt.Sprintf("period %d repeats %d", p, length/p),
+					IntegerDigits: 1,
+				})
+			}
+			i = j
+		}
+	}
+	sortMatches(res)
+	return res
+}
+
+func sortMatches(res []Match) {
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Pos != res[j].Pos {
+			return res[i].Pos < res[j].Pos
+		}
+		return res[i].Length < res[j].Length
+	})
+}
+
+func TestDetect_TandemRepeats(t *testing.T) {
+	t.Parallel()
+	assert.True(t, primitive([]byte("1")))
+	assert.True(t, primitive([]byte("1213")))
+	assert.False(t, primitive([]byte("1212")))
+	assert.False(t, primitive([]byte("777")))
+
+	d := &TandemRepeats{MaxPeriod: 4, MinLength: 6}
+	res := scan(t, d, "9"+"4567456745"+"1"+"121212"+"0", 100, 0)
+	sortMatches(res)
+	assert.Equal(t, []Match{
+		{Detector: "tandem", Pos: 1, Length: 10, Digits: "4567456745", Note: "period 4 repeats 2", IntegerDigits: 1},
+		{Detector: "tandem", Pos: 12, Length: 6, Digits: "121212", Note: "period 2 repeats 3", IntegerDigits: 1},
+	}, res)
+
+	for _, tc := range []struct {
+		digits    string
+		maxPeriod int
+		minLength int
+	}{
+		{piDigits, 10, 4},
+		{piDigits, 30, 2},
+		{strings.Repeat("0102", 30) + piDigits[:100] + strings.Repeat("123", 50), 12, 8},
+		{"5" + strings.Repeat("7", 40) + "5", 3, 2},
+	} {
+		expected := naiveTandemRepeats(tc.digits, tc.maxPeriod, tc.minLength)
+		require.NotEmpty(t, expected)
+		for _, size := range []int{1, 7, 50, len(tc.digits)} {
+			res := scan(t, &TandemRepeats{MaxPeriod: tc.maxPeriod, MinLength: tc.minLength}, tc.digits, size, 5)
+			sortMatches(res)
+			assert.Equal(t, expected, res, "size %d", size)
+		}
+	}
+}
+
+func BenchmarkTandemRepeats(b *testing.B) {
+	rnd := rand.New(rand.NewSource(1))
+	digits := make([]byte, 1_000_000)
+	for i := range digits {
+		digits[i] = byte('0' + rnd.Intn(10))
+	}
+	d := &TandemRepeats{MaxPeriod: 100, MinLength: 30}
+	b.SetBytes(int64(len(digits)))
+	for i := 0; i < b.N; i++ {
+		c := &Chunk{Digits: digits, Hi: len(digits), Radix: 10}
+		d.Detect(c, func(Match) {})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KMismatchPalindromes finds near-palindromes of odd and even length in
+// which up to K pairs of mirrored digits differ.
+//
+// For every center the mirrored pairs of digits are compared one at a time
+// outwards until the K+1-th mismatch or the end of the context of the chunk.
+// A pair of random digits differs with probability (radix-1)/radix, so the
+// scan stops after about (K+1)*radix/(radix-1) comparisons per center on
+// average, e.g. 5.6 for K = 4 in decimal, and only long near-palindromes cost
+// more. The reported palindrome is the longest one whose outermost digits
+// match, so it may have fewer than K mismatches. Like Palindromes, matches
+// are limited to the context of the chunk.
+// A match is anchored at its center (the right one for even lengths).
+type KMismatchPalindromes struct {
+	// K is the maximum number of mismatched pairs, at least 0.
+	K int
+	// MinLength is the minimum length of reported palindromes, at least 1.
+	MinLength int
+}
+
+var _ Detector = new(KMismatchPalindromes)
+
+func (p *KMismatchPalindromes) Name() string {
+	return "k-palindrome"
+}
+
+func (p *KMismatchPalindromes) Detect(c *Chunk, emit func(Match)) error {
+	if p.K < 0 || p.MinLength < 1 {
+		return fmt.Errorf("%w: k-palindrome K %d, MinLength %d", ErrInvalidParameter, p.K, p.MinLength)
+	}
+	mismatches := make([]int, 0, p.K)
+	for i := c.Lo; i < c.Hi; i++ {
+		// Odd length, centered on i.
+		mismatches = p.extend(c, i, i, mismatches, emit)
+		// Even length, centered between i-1 and i.
+		mismatches = p.extend(c, i, i-1, mismatches, emit)
+	}
+	return nil
+}
+
+// extend grows the palindrome s[lo:hi+1] one pair at a time and emits the
+// longest one found. mismatches is a reusable buffer.
+func (p *KMismatchPalindromes) extend(c *Chunk, lo, hi int, mismatches []int, emit func(Match)) []int {
+	s := c.Digits
+	mismatches = mismatches[:0]
+	bestLo, bestHi, bestN := lo, hi, 0
+	for l, r := lo-1, hi+1; l >= 0 && r < len(s); l, r = l-1, r+1 {
+		if s[l] == s[r] {
+			bestLo, bestHi, bestN = l, r, len(mismatches)
+			continue
+		}
+		if len(mismatches) == p.K {
+			break
+		}
+		mismatches = append(mismatches, l)
+	}
+	length := bestHi - bestLo + 1
+	if length < p.MinLength || length == 0 {
+		return mismatches
+	}
+	m := Match{
+		Detector:      p.Name(),
+		Pos:           c.Start + int64(bestLo),
+		Length:        length,
+		Digits:        string(s[bestLo : bestHi+1]),
+		IntegerDigits: c.IntegerDigits,
+	}
+	if bestN > 0 {
+		// Mismatches are written as pairs of positions numbered like
+		// Match.Position, from the innermost to the outermost.
+		var b strings.Builder
+		b.WriteString("mismatches")
+		for _, l := range mismatches[:bestN] {
+			r := bestLo + bestHi - l
+			fmt.Fprintf(&b, " %d/%d", c.position(l), c.position(r))
+		}
+		m.Note = b.String()
+	}
+	emit(m)
+	return mismatches
+}
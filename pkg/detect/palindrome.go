@@ -0,0 +1,83 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"fmt"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/primes"
+)
+
+// PrimeMode selects how palindromes are checked for primality.
+type PrimeMode string
+
+const (
+	PrimeOff    PrimeMode = ""
+	PrimeTag    PrimeMode = "tag"    // note "prime" or "composite" on each match
+	PrimeFilter PrimeMode = "filter" // only report prime palindromes
+)
+
+// Palindromes finds odd length palindromes.
+// A palindrome is anchored at its center digit. Palindromes are limited to
+// the context of the chunk, which should be longer than half of any
+// palindrome expected in the digits.
+type Palindromes struct {
+	// MinLength is the minimum length of reported palindromes.
+	MinLength int
+	Prime     PrimeMode
+}
+
+var _ Detector = new(Palindromes)
+
+func (p *Palindromes) Name() string {
+	return "palindrome"
+}
+
+func (p *Palindromes) Detect(c *Chunk, emit func(Match)) error {
+	s := c.Digits
+	for i := c.Lo; i < c.Hi; i++ {
+		pLen := 1
+		for i-pLen >= 0 && i+pLen < len(s) && s[i-pLen] == s[i+pLen] {
+			pLen++
+		}
+		if 2*pLen-1 < p.MinLength {
+			continue
+		}
+		m := Match{
+			Detector:      p.Name(),
+			Pos:           c.Start + int64(i-pLen+1),
+			Length:        2*pLen - 1,
+			Digits:        string(s[i-pLen+1 : i+pLen]),
+			IntegerDigits: c.IntegerDigits,
+		}
+		if p.Prime != PrimeOff {
+			isPrime, err := primes.IsPrime(m.Digits, c.Radix)
+			if err != nil {
+				return fmt.Errorf("palindrome at %d: %w", m.Pos, err)
+			}
+			if p.Prime == PrimeFilter && !isPrime {
+				continue
+			}
+			if p.Prime == PrimeTag {
+				m.Note = "composite"
+				if isPrime {
+					m.Note = "prime"
+				}
+			}
+		}
+		emit(m)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"fmt"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+)
+
+// Progressions finds arithmetic progressions of digits, where consecutive
+// digits differ by the same nonzero step modulo the radix, e.g. "0123456789",
+// "9876543" (step -1) or "8901234" (step 1, wrapping around).
+// Progressions with step 0 are found by Runs.
+// A progression is anchored at its first digit and is followed past the end
+// of the chunk if necessary. Consecutive progressions share a digit, e.g.
+// "0123210" has "0123" and "3210".
+type Progressions struct {
+	// MinLength is the minimum length of reported progressions.
+	MinLength int
+}
+
+var _ Detector = new(Progressions)
+
+func (p *Progressions) Name() string {
+	return "progression"
+}
+
+// step returns the difference between digits a and b modulo radix.
+func step(a, b byte, radix int) int {
+	return ((unpack.DigitValue(b, radix)-unpack.DigitValue(a, radix))%radix + radix) % radix
+}
+
+func (p *Progressions) Detect(c *Chunk, emit func(Match)) error {
+	for i := c.Lo; i < c.Hi; i++ {
+		if i+1 >= len(c.Digits) {
+			if _, err := c.Extend(extendSize); err != nil {
+				return err
+			}
+			if i+1 >= len(c.Digits) {
+				break
+			}
+		}
+		d := step(c.Digits[i], c.Digits[i+1], c.Radix)
+		if d == 0 || (i > 0 && step(c.Digits[i-1], c.Digits[i], c.Radix) == d) {
+			continue
+		}
+		j := i + 2
+		for {
+			for j < len(c.Digits) && step(c.Digits[j-1], c.Digits[j], c.Radix) == d {
+				j++
+			}
+			if j < len(c.Digits) {
+				break
+			}
+			n, err := c.Extend(extendSize)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				break
+			}
+		}
+		if j-i < p.MinLength {
+			continue
+		}
+		// Steps over half the radix are written as negative, e.g. -1 for 9.
+		signed := d
+		if 2*d > c.Radix {
+			signed -= c.Radix
+		}
+		emit(Match{
+			Detector:      p.Name(),
+			Pos:           c.Start + int64(i),
+			Length:        j - i,
+			Digits:        string(c.Digits[i:j]),
+			Note:          fmt.Sprintf("step %+d", signed),
+			IntegerDigits: c.IntegerDigits,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+// extendSize is the number of digits read at a time when a match reaches
+// the end of a chunk.
+const extendSize = 4096
+
+// Runs finds runs of a single digit, e.g. the Feynman point "999999".
+// A run is anchored at its first digit and is followed past the end of the
+// chunk if necessary, so a chunk needs at least one digit of context before
+// its owned digits to tell whether a run starts there.
+type Runs struct {
+	// MinLength is the minimum length of reported runs.
+	MinLength int
+}
+
+var _ Detector = new(Runs)
+
+func (r *Runs) Name() string {
+	return "run"
+}
+
+func (r *Runs) Detect(c *Chunk, emit func(Match)) error {
+	i := c.Lo
+	// Skip the rest of a run that started in the previous chunk.
+	for i > 0 && i < c.Hi && c.Digits[i-1] == c.Digits[i] {
+		i++
+	}
+	for i < c.Hi {
+		d := c.Digits[i]
+		j := i + 1
+		for {
+			for j < len(c.Digits) && c.Digits[j] == d {
+				j++
+			}
+			if j < len(c.Digits) {
+				break
+			}
+			n, err := c.Extend(extendSize)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				break
+			}
+		}
+		if j-i >= r.MinLength {
+			emit(Match{
+				Detector:      r.Name(),
+				Pos:           c.Start + int64(i),
+				Length:        j - i,
+				Digits:        string(c.Digits[i:j]),
+				IntegerDigits: c.IntegerDigits,
+			})
+		}
+		i = j
+	}
+	return nil
+}
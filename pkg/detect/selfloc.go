@@ -0,0 +1,79 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// SelfLocating finds strings of digits that occur at their own position,
+// e.g. "16470" starting at position 16470. Positions are numbered like
+// Match.Position, so the first digit after the decimal point of pi is at
+// position 1, and written in the radix of the chunk.
+// A match is anchored at its first digit.
+type SelfLocating struct{}
+
+var _ Detector = new(SelfLocating)
+
+func (s *SelfLocating) Name() string {
+	return "self-locating"
+}
+
+// increment adds 1 to the number written in repr like an odometer.
+// It returns repr, or a new slice if the number gets one digit longer.
+func increment(repr []byte, radix int) []byte {
+	last := strconv.FormatInt(int64(radix-1), radix)[0]
+	for i := len(repr) - 1; i >= 0; i-- {
+		switch {
+		case repr[i] == last:
+			repr[i] = '0'
+			continue
+		case repr[i] == '9':
+			repr[i] = 'a'
+		default:
+			repr[i]++
+		}
+		return repr
+	}
+	return append([]byte{'1'}, repr...)
+}
+
+func (s *SelfLocating) Detect(c *Chunk, emit func(Match)) error {
+	if c.Lo >= c.Hi {
+		return nil
+	}
+	repr := []byte(strconv.FormatInt(c.position(c.Lo), c.Radix))
+	for i := c.Lo; i < c.Hi; i, repr = i+1, increment(repr, c.Radix) {
+		if i+len(repr) > len(c.Digits) {
+			if _, err := c.Extend(extendSize); err != nil {
+				return err
+			}
+			if i+len(repr) > len(c.Digits) {
+				continue
+			}
+		}
+		if bytes.Equal(c.Digits[i:i+len(repr)], repr) {
+			emit(Match{
+				Detector:      s.Name(),
+				Pos:           c.Start + int64(i),
+				Length:        len(repr),
+				Digits:        string(repr),
+				IntegerDigits: c.IntegerDigits,
+			})
+		}
+	}
+	return nil
+}
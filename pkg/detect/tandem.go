@@ -0,0 +1,128 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package detect
+
+import "fmt"
+
+// TandemRepeats finds substrings immediately repeated, e.g. "XYZXYZ".
+// It reports runs: maximal substrings with the smallest period p that are at
+// least 2p long, e.g. "4567456745" (period 4, repeated 2.5 times).
+//
+// A run of period p and length at least 2p contains a position q multiple of
+// p with s[q] = s[q+p]. For every period, the positions multiple of p are
+// sampled and the run around them is found with a backward and a forward
+// longest common extension (LCE) of q and q+p. A chunk is scanned in
+// O(n log MaxPeriod) comparisons as extensions are short in random digits.
+// A run is anchored at its first digit and is followed past the end of the
+// chunk if necessary.
+type TandemRepeats struct {
+	// MaxPeriod is the largest period searched.
+	MaxPeriod int
+	// MinLength is the minimum total length of reported runs.
+	MinLength int
+}
+
+var _ Detector = new(TandemRepeats)
+
+func (t *TandemRepeats) Name() string {
+	return "tandem"
+}
+
+// primitive reports whether root isn't a repetition of a shorter string.
+func primitive(root []byte) bool {
+	p := len(root)
+	for d := 1; d < p; d++ {
+		if p%d != 0 {
+			continue
+		}
+		periodic := true
+		for i := 0; i+d < p; i++ {
+			if root[i] != root[i+d] {
+				periodic = false
+				break
+			}
+		}
+		if periodic {
+			return false
+		}
+	}
+	return true
+}
+
+// forwardLCE returns the length of the longest common prefix of the digits
+// at i and j > i, reading past the end of the chunk if necessary.
+func forwardLCE(c *Chunk, i, j int) (int, error) {
+	n := 0
+	for {
+		for j+n < len(c.Digits) && c.Digits[i+n] == c.Digits[j+n] {
+			n++
+		}
+		if j+n < len(c.Digits) {
+			return n, nil
+		}
+		read, err := c.Extend(extendSize)
+		if err != nil || read == 0 {
+			return n, err
+		}
+	}
+}
+
+func (t *TandemRepeats) Detect(c *Chunk, emit func(Match)) error {
+	s := c.Digits
+	for p := 1; p <= t.MaxPeriod; p++ {
+		for q := (c.Lo + p - 1) / p * p; q < c.Hi+p; q += p {
+			if q+p >= len(s) {
+				if _, err := c.Extend(extendSize); err != nil {
+					return err
+				}
+				if s = c.Digits; q+p >= len(s) {
+					break
+				}
+			}
+			// The run must cover q-1 or q to reach 2p digits.
+			if s[q] != s[q+p] && (q == 0 || s[q-1] != s[q+p-1]) {
+				continue
+			}
+			// Only report the run from the first multiple of p it covers,
+			// i.e. if it starts less than p digits before q.
+			b := 0
+			for b < p && q-1-b >= 0 && s[q-1-b] == s[q+p-1-b] {
+				b++
+			}
+			start := q - b
+			if b == p || start < c.Lo || start >= c.Hi {
+				continue
+			}
+			f, err := forwardLCE(c, q, q+p)
+			if err != nil {
+				return err
+			}
+			s = c.Digits
+			length := b + f + p
+			if b+f < p || length < t.MinLength || !primitive(s[start:start+p]) {
+				continue
+			}
+			emit(Match{
+				Detector:      t.Name(),
+				Pos:           c.Start + int64(start),
+				Length:        length,
+				Digits:        string(s[start : start+length]),
+				Note:          fmt.Sprintf("period %d repeats %d", p, length/p),
+				IntegerDigits: c.IntegerDigits,
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diskcache implements a persistent on-disk cache of fixed size pages
+// stacked in front of another obj.Client.
+package diskcache
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+)
+
+// DefaultPageSize is the page size used when Options.PageSize is zero.
+// Pages are byte ranges of the objects, so packed words may straddle two
+// pages after the header of a ycd file.
+const DefaultPageSize = 1 * 1024 * 1024 // 1 MiB
+
+// Options configures the cache.
+type Options struct {
+	// Dir is the directory to store pages in. It is created if it doesn't exist.
+	Dir string
+	// MaxBytes is the maximum total size of page files in Dir.
+	// Zero or negative means unlimited.
+	MaxBytes int64
+	// PageSize is the number of bytes fetched from upstream at once.
+	PageSize int64
+}
+
+// Stats are the counters of a cache.
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	// PutErrors is the number of pages read from upstream that couldn't be
+	// written to the cache, e.g. because the disk is full.
+	PutErrors int64
+	// Pages is the number of pages currently in the cache.
+	Pages int
+	// Bytes is the total size of page files currently in the cache.
+	Bytes int64
+}
+
+// Client is an obj.Client that caches pages read from the upstream client on disk.
+type Client struct {
+	upstream obj.Client
+	store    *store
+	pageSize int64
+}
+
+type Bucket struct {
+	c    *Client
+	name string
+	h    obj.Bucket
+}
+
+type Object struct {
+	b    *Bucket
+	name string
+	h    obj.Object
+}
+
+var _ obj.Client = new(Client)
+var _ obj.Sizer = new(Object)
+
+// NewClient returns a new Client caching reads from upstream in opts.Dir.
+// Pages already in the directory are reused and validated on read.
+func NewClient(upstream obj.Client, opts Options) (*Client, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("diskcache: empty directory")
+	}
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize < 0 {
+		return nil, errors.New("diskcache: negative page size")
+	}
+	s, err := openStore(opts.Dir, opts.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		upstream: upstream,
+		store:    s,
+		pageSize: pageSize,
+	}, nil
+}
+
+func (c *Client) Bucket(name string) obj.Bucket {
+	return &Bucket{c: c, name: name, h: c.upstream.Bucket(name)}
+}
+
+// Close closes the upstream client.
+func (c *Client) Close() error {
+	return c.upstream.Close()
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *Client) Stats() Stats {
+	return c.store.snapshot()
+}
+
+func (b *Bucket) Object(name string) obj.Object {
+	return &Object{b: b, name: name, h: b.h.Object(name)}
+}
+
+// NewRangeReader returns a reader for [offset, offset+length) of the object.
+// If length is negative, it reads until the end of the object.
+// Pages are fetched from upstream only if they are not in the cache.
+func (o *Object) NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("diskcache: negative offset")
+	}
+	r := &reader{
+		ctx: ctx,
+		o:   o,
+		off: offset,
+		end: -1,
+	}
+	if length >= 0 {
+		r.end = offset + length
+	}
+	// Load the first page now so range errors surface here as they do upstream.
+	if length != 0 {
+		if err := r.load(); err != nil {
+			return nil, err
+		}
+		if r.off-r.pageN*o.b.c.pageSize >= int64(len(r.page)) {
+			return nil, io.EOF
+		}
+	}
+	return r, nil
+}
+
+// Size returns the size of the object from upstream.
+// It returns obj.ErrNoSize if the upstream object doesn't implement obj.Sizer.
+func (o *Object) Size(ctx context.Context) (int64, error) {
+	sizer, ok := o.h.(obj.Sizer)
+	if !ok {
+		return 0, obj.ErrNoSize
+	}
+	return sizer.Size(ctx)
+}
+
+// page returns the content of the i-th page of the object.
+func (o *Object) page(ctx context.Context, i int64) ([]byte, error) {
+	c := o.b.c
+	key := pageKey(o.b.name, o.name, c.pageSize, i)
+	if data, ok := c.store.get(key); ok {
+		return data, nil
+	}
+
+	rd, err := o.h.NewRangeReader(ctx, i*c.pageSize, c.pageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	// The cache is only an optimization: a page that can't be stored is
+	// counted and read from upstream again next time.
+	if len(data) > 0 {
+		if err := c.store.put(key, data); err != nil {
+			c.store.countPutError()
+		}
+	}
+	return data, nil
+}
+
+// reader reads a range of an object page by page.
+type reader struct {
+	ctx   context.Context
+	o     *Object
+	off   int64
+	end   int64 // -1 if unbounded
+	page  []byte
+	pageN int64 // index of page, valid if page != nil
+}
+
+func (r *reader) load() error {
+	i := r.off / r.o.b.c.pageSize
+	if r.page != nil && r.pageN == i {
+		return nil
+	}
+	data, err := r.o.page(r.ctx, i)
+	if err != nil {
+		return err
+	}
+	r.page = data
+	r.pageN = i
+	return nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if r.end >= 0 && r.off >= r.end {
+			break
+		}
+		if err := r.load(); err != nil {
+			return n, err
+		}
+		pos := r.off - r.pageN*r.o.b.c.pageSize
+		if pos >= int64(len(r.page)) {
+			// Short or empty page: end of the object.
+			break
+		}
+		avail := r.page[pos:]
+		if r.end >= 0 && int64(len(avail)) > r.end-r.off {
+			avail = avail[:r.end-r.off]
+		}
+		read := copy(p[n:], avail)
+		n += read
+		r.off += int64(read)
+	}
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+func (r *reader) Close() error {
+	r.page = nil
+	return nil
+}
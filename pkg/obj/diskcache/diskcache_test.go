@@ -0,0 +1,241 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskcache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mock_obj "github.com/googlecloudplatform/pi-delivery/pkg/obj/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBucket = "bucket"
+	testObject = "object"
+)
+
+func genTestByteSeq(n int) []byte {
+	buf := make([]byte, n)
+	for i := 0; i < n; i++ {
+		buf[i] = byte(i)
+	}
+	return buf
+}
+
+// newUpstream returns a mock client serving buf as testObject and
+// a counter of upstream range requests.
+func newUpstream(t *testing.T, buf []byte) (*mock_obj.MockClient, *int64) {
+	mockCtrl := gomock.NewController(t)
+	client := mock_obj.NewMockClient(mockCtrl)
+	bucket := mock_obj.NewMockBucket(mockCtrl)
+	object := mock_obj.NewMockObject(mockCtrl)
+	var calls int64
+
+	client.EXPECT().Bucket(testBucket).Return(bucket).AnyTimes()
+	client.EXPECT().Close().Return(nil).AnyTimes()
+	bucket.EXPECT().Object(testObject).Return(object).AnyTimes()
+	object.EXPECT().NewRangeReader(
+		gomock.Any(),
+		gomock.Any(),
+		gomock.Any(),
+	).DoAndReturn(
+		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+			atomic.AddInt64(&calls, 1)
+			if off >= int64(len(buf)) {
+				return nil, io.EOF
+			}
+			end := off + length
+			if length < 0 || end > int64(len(buf)) {
+				end = int64(len(buf))
+			}
+			return io.NopCloser(bytes.NewReader(buf[off:end])), nil
+		},
+	).AnyTimes()
+	return client, &calls
+}
+
+func readRange(t *testing.T, c *Client, off, length int64) []byte {
+	rd, err := c.Bucket(testBucket).Object(testObject).NewRangeReader(context.Background(), off, length)
+	require.NoError(t, err)
+	defer rd.Close()
+	buf, err := io.ReadAll(rd)
+	require.NoError(t, err)
+	return buf
+}
+
+func TestDiskCache_Read(t *testing.T) {
+	t.Parallel()
+	testBuf := genTestByteSeq(1000)
+	upstream, calls := newUpstream(t, testBuf)
+
+	c, err := NewClient(upstream, Options{Dir: t.TempDir(), PageSize: 64})
+	require.NoError(t, err)
+	defer assert.NoError(t, c.Close())
+
+	testCases := []struct {
+		off, length int64
+	}{
+		{0, 10},
+		{0, 64},
+		{60, 10},
+		{63, 200},
+		{950, 50},
+		{950, 100},
+		{990, -1},
+		{500, 0},
+	}
+	for _, tc := range testCases {
+		end := tc.off + tc.length
+		if tc.length < 0 || end > int64(len(testBuf)) {
+			end = int64(len(testBuf))
+		}
+		assert.Equal(t, testBuf[tc.off:end], readRange(t, c, tc.off, tc.length),
+			"off = %d, length = %d", tc.off, tc.length)
+	}
+
+	// All pages should be cached now.
+	readRange(t, c, 0, -1)
+	before := atomic.LoadInt64(calls)
+	assert.Equal(t, testBuf, readRange(t, c, 0, -1))
+	assert.Equal(t, before, atomic.LoadInt64(calls))
+
+	st := c.Stats()
+	assert.Equal(t, 16, st.Pages)
+	assert.NotZero(t, st.Hits)
+	assert.NotZero(t, st.Misses)
+	assert.Zero(t, st.Evictions)
+
+	// Reading at the end of the object returns the upstream error.
+	_, err = c.Bucket(testBucket).Object(testObject).NewRangeReader(context.Background(), 1000, 10)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDiskCache_Persistent(t *testing.T) {
+	t.Parallel()
+	testBuf := genTestByteSeq(300)
+	dir := t.TempDir()
+
+	upstream, _ := newUpstream(t, testBuf)
+	c, err := NewClient(upstream, Options{Dir: dir, PageSize: 64})
+	require.NoError(t, err)
+	assert.Equal(t, testBuf, readRange(t, c, 0, -1))
+
+	// A leftover temporary file from a crash must be cleaned up.
+	tmp := filepath.Join(dir, tmpPrefix+"crashed")
+	require.NoError(t, os.WriteFile(tmp, []byte("garbage"), 0644))
+
+	upstream, calls := newUpstream(t, testBuf)
+	c, err = NewClient(upstream, Options{Dir: dir, PageSize: 64})
+	require.NoError(t, err)
+	assert.Equal(t, testBuf, readRange(t, c, 0, -1))
+	assert.Zero(t, atomic.LoadInt64(calls))
+	assert.Equal(t, 5, c.Stats().Pages)
+
+	_, err = os.Stat(tmp)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDiskCache_Corruption(t *testing.T) {
+	t.Parallel()
+	testBuf := genTestByteSeq(256)
+	dir := t.TempDir()
+	upstream, calls := newUpstream(t, testBuf)
+
+	c, err := NewClient(upstream, Options{Dir: dir, PageSize: 64})
+	require.NoError(t, err)
+	assert.Equal(t, testBuf, readRange(t, c, 0, -1))
+	before := atomic.LoadInt64(calls)
+
+	// Flip a byte in the first page.
+	path := c.store.path(pageKey(testBucket, testObject, 64, 0))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[pageHeaderSize] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	_, err = readPage(path)
+	assert.ErrorIs(t, err, ErrCorruptPage)
+
+	// The corrupt page must be fetched again.
+	assert.Equal(t, testBuf[:64], readRange(t, c, 0, 64))
+	assert.Equal(t, before+1, atomic.LoadInt64(calls))
+
+	_, err = readPage(path)
+	assert.NoError(t, err)
+}
+
+func TestDiskCache_Eviction(t *testing.T) {
+	t.Parallel()
+	testBuf := genTestByteSeq(640)
+	upstream, calls := newUpstream(t, testBuf)
+
+	// Room for 3 pages.
+	const pageSize = 64
+	c, err := NewClient(upstream, Options{
+		Dir:      t.TempDir(),
+		PageSize: pageSize,
+		MaxBytes: 3 * (pageSize + pageHeaderSize),
+	})
+	require.NoError(t, err)
+
+	for i := int64(0); i < 10; i++ {
+		assert.Equal(t, testBuf[i*pageSize:(i+1)*pageSize], readRange(t, c, i*pageSize, pageSize))
+	}
+	st := c.Stats()
+	assert.Equal(t, 3, st.Pages)
+	assert.Equal(t, int64(3*(pageSize+pageHeaderSize)), st.Bytes)
+	assert.Equal(t, int64(7), st.Evictions)
+
+	// Touch page 7 so page 8 becomes the least recently used one.
+	readRange(t, c, 7*pageSize, pageSize)
+	readRange(t, c, 0, pageSize)
+	before := atomic.LoadInt64(calls)
+	readRange(t, c, 7*pageSize, pageSize)
+	readRange(t, c, 9*pageSize, pageSize)
+	assert.Equal(t, before, atomic.LoadInt64(calls))
+	readRange(t, c, 8*pageSize, pageSize)
+	assert.Equal(t, before+1, atomic.LoadInt64(calls))
+}
+
+func TestDiskCache_PutError(t *testing.T) {
+	t.Parallel()
+	testBuf := genTestByteSeq(200)
+	upstream, calls := newUpstream(t, testBuf)
+	dir := filepath.Join(t.TempDir(), "cache")
+	c, err := NewClient(upstream, Options{Dir: dir, PageSize: 64})
+	require.NoError(t, err)
+	defer assert.NoError(t, c.Close())
+
+	// Pages can't be written once the directory is replaced with a file.
+	require.NoError(t, os.RemoveAll(dir))
+	require.NoError(t, os.WriteFile(dir, nil, 0644))
+
+	assert.Equal(t, testBuf, readRange(t, c, 0, -1))
+	before := atomic.LoadInt64(calls)
+	assert.Equal(t, testBuf[10:100], readRange(t, c, 10, 90))
+	assert.Equal(t, before+2, atomic.LoadInt64(calls))
+
+	st := c.Stats()
+	assert.Equal(t, int64(6), st.PutErrors)
+	assert.Zero(t, st.Pages)
+}
@@ -0,0 +1,311 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Page files start with a fixed size header:
+//
+//	magic (4 bytes) | data length (uint32, LE) | CRC-32C of data (uint32, LE)
+const (
+	pageMagic      = "PDC1"
+	pageHeaderSize = 12
+	pageSuffix     = ".page"
+	tmpPrefix      = "tmp-"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrCorruptPage is returned when a page file fails validation.
+var ErrCorruptPage = errors.New("diskcache: corrupt page")
+
+type entry struct {
+	key  string
+	size int64
+}
+
+// store is a size-limited LRU set of page files in a directory.
+type store struct {
+	dir      string
+	maxBytes int64
+
+	lock    sync.Mutex
+	lru     *list.List // front is the most recently used.
+	entries map[string]*list.Element
+	total   int64
+	stats   Stats
+}
+
+func openStore(dir string, maxBytes int64) (*store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &store{
+		dir:      dir,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load rebuilds the LRU index from the files in the directory.
+// Leftover temporary files from interrupted writes are removed.
+func (s *store) load() error {
+	type found struct {
+		key   string
+		size  int64
+		mtime time.Time
+	}
+	var pages []found
+
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if strings.HasPrefix(name, tmpPrefix) {
+			return os.Remove(path)
+		}
+		if !strings.HasSuffix(name, pageSuffix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		pages = append(pages, found{
+			key:   strings.TrimSuffix(name, pageSuffix),
+			size:  info.Size(),
+			mtime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].mtime.After(pages[j].mtime)
+	})
+	for _, p := range pages {
+		s.entries[p.key] = s.lru.PushBack(&entry{key: p.key, size: p.size})
+		s.total += p.size
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.evictLocked()
+}
+
+// pageKey returns the file key for a page of an object.
+func pageKey(bucket, object string, pageSize, page int64) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00%d", bucket, object, pageSize, page)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *store) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key+pageSuffix)
+}
+
+// get returns the content of the page for key.
+// ok is false if the page doesn't exist or is corrupt.
+func (s *store) get(key string) ([]byte, bool) {
+	s.lock.Lock()
+	e, ok := s.entries[key]
+	if ok {
+		s.lru.MoveToFront(e)
+	}
+	s.lock.Unlock()
+	if !ok {
+		s.countMiss()
+		return nil, false
+	}
+
+	path := s.path(key)
+	data, err := readPage(path)
+	if err != nil {
+		// Drop pages that can't be read or fail validation.
+		s.remove(key)
+		s.countMiss()
+		return nil, false
+	}
+	// Keep the access order across restarts. Failures are harmless.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	s.lock.Lock()
+	s.stats.Hits++
+	s.lock.Unlock()
+	return data, true
+}
+
+// put stores data as the page for key and evicts old pages as needed.
+func (s *store) put(key string, data []byte) error {
+	path := s.path(key)
+	if err := writePage(path, data); err != nil {
+		return err
+	}
+	size := int64(pageHeaderSize + len(data))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.total -= e.Value.(*entry).size
+		e.Value.(*entry).size = size
+		s.lru.MoveToFront(e)
+	} else {
+		s.entries[key] = s.lru.PushFront(&entry{key: key, size: size})
+	}
+	s.total += size
+	return s.evictLocked()
+}
+
+func (s *store) remove(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if e, ok := s.entries[key]; ok {
+		s.removeLocked(e)
+	}
+}
+
+func (s *store) removeLocked(e *list.Element) error {
+	ent := e.Value.(*entry)
+	s.lru.Remove(e)
+	delete(s.entries, ent.key)
+	s.total -= ent.size
+	if err := os.Remove(s.path(ent.key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// evictLocked removes the least recently used pages until the total size
+// is within the limit.
+func (s *store) evictLocked() error {
+	if s.maxBytes <= 0 {
+		return nil
+	}
+	for s.total > s.maxBytes && s.lru.Len() > 0 {
+		if err := s.removeLocked(s.lru.Back()); err != nil {
+			return err
+		}
+		s.stats.Evictions++
+	}
+	return nil
+}
+
+func (s *store) countMiss() {
+	s.lock.Lock()
+	s.stats.Misses++
+	s.lock.Unlock()
+}
+
+func (s *store) countPutError() {
+	s.lock.Lock()
+	s.stats.PutErrors++
+	s.lock.Unlock()
+}
+
+func (s *store) snapshot() Stats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	st := s.stats
+	st.Pages = s.lru.Len()
+	st.Bytes = s.total
+	return st
+}
+
+// readPage reads and validates a page file.
+func readPage(path string) ([]byte, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < pageHeaderSize || string(buf[:4]) != pageMagic {
+		return nil, fmt.Errorf("%w: %s: bad header", ErrCorruptPage, path)
+	}
+	length := binary.LittleEndian.Uint32(buf[4:])
+	sum := binary.LittleEndian.Uint32(buf[8:])
+	data := buf[pageHeaderSize:]
+	if int64(length) != int64(len(data)) {
+		return nil, fmt.Errorf("%w: %s: length = %d, expected %d",
+			ErrCorruptPage, path, len(data), length)
+	}
+	if crc32.Checksum(data, crcTable) != sum {
+		return nil, fmt.Errorf("%w: %s: checksum mismatch", ErrCorruptPage, path)
+	}
+	return data, nil
+}
+
+// writePage atomically writes a page file. The content is written to a
+// temporary file in the same directory, synced and renamed so a crash never
+// leaves a partially written page behind.
+func writePage(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+
+	var header [pageHeaderSize]byte
+	copy(header[:], pageMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[8:], crc32.Checksum(data, crcTable))
+
+	if _, err := f.Write(header[:]); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -61,3 +61,12 @@ func (b *Bucket) Object(name string) obj.Object {
 func (o *Object) NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
 	return o.h.NewRangeReader(ctx, offset, length)
 }
+
+// Size returns the size of the object from its attributes.
+func (o *Object) Size(ctx context.Context) (int64, error) {
+	attrs, err := o.h.Attrs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return attrs.Size, nil
+}
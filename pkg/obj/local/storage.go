@@ -0,0 +1,106 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+)
+
+// Implementations for the local file system.
+// Buckets are directories under the root directory and objects are files
+// in them, e.g. a copy made by `gsutil -m rsync -R gs://pi100t ROOT/pi100t`.
+
+type Client struct {
+	root string
+}
+
+type Bucket struct {
+	dir string
+}
+
+type Object struct {
+	path string
+}
+
+// NewClient returns a new client reading buckets under root.
+func NewClient(root string) obj.Client {
+	return &Client{root: root}
+}
+
+func (c *Client) Bucket(name string) obj.Bucket {
+	return &Bucket{dir: filepath.Join(c.root, name)}
+}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+func (b *Bucket) Object(name string) obj.Object {
+	return &Object{path: filepath.Join(b.dir, filepath.FromSlash(name))}
+}
+
+type rangeReader struct {
+	io.Reader
+	f *os.File
+}
+
+func (r *rangeReader) Close() error {
+	return r.f.Close()
+}
+
+// NewRangeReader returns a reader for [offset, offset+length) of the file.
+// If length is negative, it reads until the end of the file.
+// It returns io.EOF if offset is at or beyond the end of the file.
+func (o *Object) NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("NewRangeReader: negative offset")
+	}
+	f, err := os.Open(o.path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	size := info.Size()
+	if offset >= size {
+		f.Close()
+		return nil, io.EOF
+	}
+	if length < 0 || offset+length > size {
+		length = size - offset
+	}
+	return &rangeReader{
+		Reader: io.NewSectionReader(f, offset, length),
+		f:      f,
+	}, nil
+}
+
+// Size returns the size of the file.
+func (o *Object) Size(ctx context.Context) (int64, error) {
+	info, err := os.Stat(o.path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal_NewRangeReader(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	const name = "Pi - Dec - Chudnovsky/Pi - Dec - Chudnovsky - 0.ycd"
+	content := []byte("0123456789")
+
+	path := filepath.Join(root, "bucket", filepath.FromSlash(name))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	client := NewClient(root)
+	defer assert.NoError(t, client.Close())
+	object := client.Bucket("bucket").Object(name)
+
+	testCases := []struct {
+		off, length int64
+		expected    string
+	}{
+		{0, 10, "0123456789"},
+		{0, -1, "0123456789"},
+		{3, 4, "3456"},
+		{8, 10, "89"},
+		{9, -1, "9"},
+		{5, 0, ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("Off %d Length %d", tc.off, tc.length), func(t *testing.T) {
+			rd, err := object.NewRangeReader(context.Background(), tc.off, tc.length)
+			require.NoError(t, err)
+			buf, err := io.ReadAll(rd)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(buf))
+			assert.NoError(t, rd.Close())
+		})
+	}
+
+	_, err := object.NewRangeReader(context.Background(), 10, 1)
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = object.NewRangeReader(context.Background(), -1, 1)
+	assert.Error(t, err)
+	_, err = client.Bucket("bucket").Object("missing").NewRangeReader(context.Background(), 0, 1)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLocal_Size(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "bucket"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "bucket", "object"), []byte("0123456789"), 0644))
+
+	client := NewClient(root)
+	size, err := client.Bucket("bucket").Object("object").(obj.Sizer).Size(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+	_, err = client.Bucket("bucket").Object("missing").(obj.Sizer).Size(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
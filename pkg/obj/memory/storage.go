@@ -0,0 +1,209 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+)
+
+// Implementations storing objects in memory, for tests and local stand-ins.
+// Range readers behave like the other backends: they return io.EOF if the
+// offset is at or beyond the end of the object and fewer bytes than requested
+// if the range extends past the end. Failures can be injected with Faults.
+
+var ErrNotExist = errors.New("memory: object doesn't exist")
+
+// Faults describes failures injected into range readers.
+// The zero value injects no failures.
+type Faults struct {
+	// Latency delays NewRangeReader. The delay is cut short if the context
+	// is done, in which case NewRangeReader returns the context error.
+	Latency time.Duration
+	// ReadSize limits the number of bytes returned by each Read if positive.
+	ReadSize int
+	// Err is returned by Read once ErrAfter bytes were read from a range
+	// reader if not nil.
+	Err error
+	// ErrAfter is the number of bytes a range reader returns before Err.
+	ErrAfter int64
+}
+
+// Open waits for Latency, calls open and injects the read failures into the
+// returned reader. Wrappers of other buckets use it to inject the same faults.
+func (f Faults) Open(ctx context.Context, open func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	if f.Latency > 0 {
+		timer := time.NewTimer(f.Latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	rd, err := open()
+	if err != nil {
+		return nil, err
+	}
+	if f.ReadSize <= 0 && f.Err == nil {
+		return rd, nil
+	}
+	return &faultyReader{ReadCloser: rd, faults: f}, nil
+}
+
+type faultyReader struct {
+	io.ReadCloser
+	faults Faults
+	read   int64
+}
+
+func (r *faultyReader) Read(p []byte) (int, error) {
+	if r.faults.Err != nil {
+		left := r.faults.ErrAfter - r.read
+		if left <= 0 {
+			return 0, r.faults.Err
+		}
+		if int64(len(p)) > left {
+			p = p[:left]
+		}
+	}
+	if r.faults.ReadSize > 0 && len(p) > r.faults.ReadSize {
+		p = p[:r.faults.ReadSize]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+type Client struct {
+	lock    sync.RWMutex
+	buckets map[string]map[string][]byte
+	faults  Faults
+}
+
+type Bucket struct {
+	c    *Client
+	name string
+}
+
+type Object struct {
+	c            *Client
+	bucket, name string
+}
+
+var _ obj.Client = new(Client)
+var _ obj.Sizer = new(Object)
+
+// NewClient returns a new client with no buckets.
+func NewClient() *Client {
+	return &Client{
+		buckets: make(map[string]map[string][]byte),
+	}
+}
+
+// Put stores data as the object name in bucket, replacing any existing object.
+// data must not be modified afterwards.
+func (c *Client) Put(bucket, name string, data []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	b, ok := c.buckets[bucket]
+	if !ok {
+		b = make(map[string][]byte)
+		c.buckets[bucket] = b
+	}
+	b[name] = data
+}
+
+// SetFaults sets the failures injected into range readers created afterwards.
+func (c *Client) SetFaults(f Faults) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.faults = f
+}
+
+func (c *Client) Bucket(name string) obj.Bucket {
+	return &Bucket{c: c, name: name}
+}
+
+func (c *Client) Close() error {
+	return nil
+}
+
+func (b *Bucket) Object(name string) obj.Object {
+	return &Object{c: b.c, bucket: b.name, name: name}
+}
+
+// NewRangeReader returns a reader for [offset, offset+length) of the object.
+// If length is negative, it reads until the end of the object.
+// It returns io.EOF if offset is at or beyond the end of the object.
+func (o *Object) NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+	o.c.lock.RLock()
+	data, ok := o.c.buckets[o.bucket][o.name]
+	faults := o.c.faults
+	o.c.lock.RUnlock()
+
+	return faults.Open(ctx, func() (io.ReadCloser, error) {
+		if !ok {
+			return nil, fmt.Errorf("%w: %s/%s", ErrNotExist, o.bucket, o.name)
+		}
+		if offset < 0 {
+			return nil, errors.New("NewRangeReader: negative offset")
+		}
+		size := int64(len(data))
+		if offset >= size {
+			return nil, io.EOF
+		}
+		end := size
+		if length >= 0 && offset+length < size {
+			end = offset + length
+		}
+		return &rangeReader{data: data[offset:end]}, nil
+	})
+}
+
+// Size returns the size of the object.
+func (o *Object) Size(ctx context.Context) (int64, error) {
+	o.c.lock.RLock()
+	data, ok := o.c.buckets[o.bucket][o.name]
+	o.c.lock.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrNotExist, o.bucket, o.name)
+	}
+	return int64(len(data)), nil
+}
+
+type rangeReader struct {
+	data []byte
+	read int64
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.read >= int64(len(r.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[r.read:])
+	r.read += int64(n)
+	return n, nil
+}
+
+func (r *rangeReader) Close() error {
+	return nil
+}
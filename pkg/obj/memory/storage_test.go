@@ -0,0 +1,145 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_NewRangeReader(t *testing.T) {
+	t.Parallel()
+	const name = "Pi - Dec - Chudnovsky/Pi - Dec - Chudnovsky - 0.ycd"
+
+	client := NewClient()
+	defer assert.NoError(t, client.Close())
+	client.Put("bucket", name, []byte("0123456789"))
+	object := client.Bucket("bucket").Object(name)
+
+	testCases := []struct {
+		off, length int64
+		expected    string
+	}{
+		{0, 10, "0123456789"},
+		{0, -1, "0123456789"},
+		{3, 4, "3456"},
+		{8, 10, "89"},
+		{9, -1, "9"},
+		{5, 0, ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("Off %d Length %d", tc.off, tc.length), func(t *testing.T) {
+			rd, err := object.NewRangeReader(context.Background(), tc.off, tc.length)
+			require.NoError(t, err)
+			buf, err := io.ReadAll(rd)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(buf))
+			assert.NoError(t, rd.Close())
+		})
+	}
+
+	_, err := object.NewRangeReader(context.Background(), 10, 1)
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = object.NewRangeReader(context.Background(), -1, 1)
+	assert.Error(t, err)
+	_, err = client.Bucket("bucket").Object("missing").NewRangeReader(context.Background(), 0, 1)
+	assert.ErrorIs(t, err, ErrNotExist)
+	_, err = client.Bucket("other").Object(name).NewRangeReader(context.Background(), 0, 1)
+	assert.ErrorIs(t, err, ErrNotExist)
+
+	client.Put("bucket", name, []byte("abc"))
+	rd, err := object.NewRangeReader(context.Background(), 1, -1)
+	require.NoError(t, err)
+	buf, err := io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, "bc", string(buf))
+}
+
+func TestMemory_Size(t *testing.T) {
+	t.Parallel()
+	client := NewClient()
+	client.Put("bucket", "object", []byte("0123456789"))
+	size, err := client.Bucket("bucket").Object("object").(obj.Sizer).Size(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), size)
+	_, err = client.Bucket("bucket").Object("missing").(obj.Sizer).Size(context.Background())
+	assert.ErrorIs(t, err, ErrNotExist)
+}
+
+func TestMemory_Faults(t *testing.T) {
+	t.Parallel()
+	errFault := errors.New("fault")
+	client := NewClient()
+	client.Put("bucket", "object", []byte("0123456789"))
+	object := client.Bucket("bucket").Object("object")
+	ctx := context.Background()
+
+	// Short reads.
+	client.SetFaults(Faults{ReadSize: 3})
+	rd, err := object.NewRangeReader(ctx, 1, -1)
+	require.NoError(t, err)
+	buf := make([]byte, 10)
+	n, err := rd.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "123", string(buf[:n]))
+	rest, err := io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, "456789", string(rest))
+
+	// Errors after N bytes, for every reader.
+	client.SetFaults(Faults{Err: errFault, ErrAfter: 4})
+	for i := 0; i < 2; i++ {
+		rd, err = object.NewRangeReader(ctx, 2, 6)
+		require.NoError(t, err)
+		n, err = io.ReadFull(rd, buf)
+		assert.ErrorIs(t, err, errFault)
+		assert.Equal(t, "2345", string(buf[:n]))
+	}
+	// Ranges shorter than ErrAfter end normally.
+	rd, err = object.NewRangeReader(ctx, 2, 3)
+	require.NoError(t, err)
+	rest, err = io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, "234", string(rest))
+
+	// Latency.
+	client.SetFaults(Faults{Latency: 10 * time.Millisecond})
+	start := time.Now()
+	_, err = object.NewRangeReader(ctx, 0, 1)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	client.SetFaults(Faults{Latency: time.Hour})
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = object.NewRangeReader(cctx, 0, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	client.SetFaults(Faults{})
+	rd, err = object.NewRangeReader(ctx, 0, -1)
+	require.NoError(t, err)
+	rest, err = io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", string(rest))
+}
@@ -138,3 +138,41 @@ func (mr *MockObjectMockRecorder) NewRangeReader(ctx, offset, length interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRangeReader", reflect.TypeOf((*MockObject)(nil).NewRangeReader), ctx, offset, length)
 }
+
+// MockSizer is a mock of Sizer interface.
+type MockSizer struct {
+	ctrl     *gomock.Controller
+	recorder *MockSizerMockRecorder
+}
+
+// MockSizerMockRecorder is the mock recorder for MockSizer.
+type MockSizerMockRecorder struct {
+	mock *MockSizer
+}
+
+// NewMockSizer creates a new mock instance.
+func NewMockSizer(ctrl *gomock.Controller) *MockSizer {
+	mock := &MockSizer{ctrl: ctrl}
+	mock.recorder = &MockSizerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSizer) EXPECT() *MockSizerMockRecorder {
+	return m.recorder
+}
+
+// Size mocks base method.
+func (m *MockSizer) Size(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Size", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Size indicates an expected call of Size.
+func (mr *MockSizerMockRecorder) Size(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Size", reflect.TypeOf((*MockSizer)(nil).Size), ctx)
+}
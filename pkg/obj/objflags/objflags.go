@@ -0,0 +1,84 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package objflags defines the command line flags selecting where the
+// commands read the digits from and creates the matching client.
+package objflags
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/diskcache"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/gcs"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/local"
+)
+
+// Flags are the values of the flags after parsing.
+type Flags struct {
+	// Bucket is the bucket containing the digits.
+	Bucket string
+	// Local is the directory holding buckets as subdirectories.
+	// GCS is used if empty.
+	Local string
+	// CacheDir is the directory of the disk cache, disabled if empty.
+	CacheDir string
+	// CacheSize is the maximum size of the disk cache in bytes.
+	CacheSize int64
+}
+
+// Register defines -bucket and -local in fs. defaultBucket is the default
+// value of -bucket.
+func Register(fs *flag.FlagSet, defaultBucket string) *Flags {
+	f := new(Flags)
+	fs.StringVar(&f.Bucket, "bucket", defaultBucket, "Bucket containing the digits")
+	fs.StringVar(&f.Local, "local", "", "Read buckets from subdirectories of this directory instead of GCS")
+	return f
+}
+
+// RegisterCache defines -cache-dir and -cache-size in fs. defaultSize is the
+// default value of -cache-size, 0 for unlimited.
+func (f *Flags) RegisterCache(fs *flag.FlagSet, defaultSize int64) {
+	fs.StringVar(&f.CacheDir, "cache-dir", "", "Directory to cache packed digits in (disabled if empty)")
+	fs.Int64Var(&f.CacheSize, "cache-size", defaultSize, "Maximum size of the disk cache in bytes (0 is unlimited)")
+}
+
+// NewClient returns a client reading from the local directory or GCS,
+// wrapped in a disk cache if enabled. The caller must close it.
+func (f *Flags) NewClient(ctx context.Context) (obj.Client, error) {
+	var client obj.Client
+	if f.Local != "" {
+		client = local.NewClient(f.Local)
+	} else {
+		var err error
+		client, err = gcs.NewClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create a GCS client: %w", err)
+		}
+	}
+	if f.CacheDir == "" {
+		return client, nil
+	}
+	cached, err := diskcache.NewClient(client, diskcache.Options{
+		Dir:      f.CacheDir,
+		MaxBytes: f.CacheSize,
+	})
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("couldn't open the disk cache: %w", err)
+	}
+	return cached, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package objflags
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/diskcache"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlags_Defaults(t *testing.T) {
+	t.Parallel()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f := Register(fs, "pi100t")
+	f.RegisterCache(fs, 1024)
+	require.NoError(t, fs.Parse(nil))
+	assert.Equal(t, &Flags{Bucket: "pi100t", CacheSize: 1024}, f)
+}
+
+func TestFlags_NewClient(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	root := t.TempDir()
+	path := filepath.Join(root, "bucket", "digits.ycd")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0644))
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		cached bool
+	}{
+		{"Local", []string{"-bucket", "bucket", "-local", root}, false},
+		{"Cached", []string{"-bucket", "bucket", "-local", root, "-cache-dir", t.TempDir()}, true},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		f := Register(fs, "pi100t")
+		f.RegisterCache(fs, 0)
+		require.NoError(t, fs.Parse(tc.args), tc.name)
+
+		client, err := f.NewClient(ctx)
+		require.NoError(t, err, tc.name)
+		if tc.cached {
+			assert.IsType(t, &diskcache.Client{}, client, tc.name)
+		} else {
+			assert.IsType(t, &local.Client{}, client, tc.name)
+		}
+		rd, err := client.Bucket(f.Bucket).Object("digits.ycd").NewRangeReader(ctx, 2, 3)
+		require.NoError(t, err, tc.name)
+		data, err := io.ReadAll(rd)
+		rd.Close()
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, "234", string(data), tc.name)
+		assert.NoError(t, client.Close(), tc.name)
+	}
+}
@@ -16,6 +16,7 @@ package obj
 
 import (
 	"context"
+	"errors"
 	"io"
 )
 
@@ -41,3 +42,14 @@ type Object interface {
 	// for the object.
 	NewRangeReader(ctx context.Context, offset, length int64) (io.ReadCloser, error)
 }
+
+// ErrNoSize is returned by Sizer implementations wrapping an object that
+// doesn't implement Sizer.
+var ErrNoSize = errors.New("obj: size not available")
+
+// Sizer is an optional interface implemented by objects that can report their
+// size without reading them.
+type Sizer interface {
+	// Size returns the size of the object in bytes.
+	Size(ctx context.Context) (int64, error)
+}
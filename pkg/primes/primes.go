@@ -0,0 +1,177 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package primes implements primality tests for digit strings found in the results.
+package primes
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+)
+
+var ErrInvalidNumber = errors.New("primes: invalid number")
+
+// trialLimit is the bound of the small primes used for trial division.
+const trialLimit = 1000
+
+// smallPrimes are the primes below trialLimit.
+var smallPrimes = sieve(trialLimit)
+
+// mrBases are the Miller-Rabin bases that make the test deterministic
+// for every n < 3,317,044,064,679,887,385,961,981 (> 2^64).
+var mrBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+// mrBigLimit is the bound below which mrBases are proven to be sufficient.
+var mrBigLimit, _ = new(big.Int).SetString("3317044064679887385961981", 10)
+
+func sieve(n int) []uint64 {
+	composite := make([]bool, n)
+	var res []uint64
+	for i := 2; i < n; i++ {
+		if composite[i] {
+			continue
+		}
+		res = append(res, uint64(i))
+		for j := i * i; j < n; j += i {
+			composite[j] = true
+		}
+	}
+	return res
+}
+
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+func powMod(b, e, m uint64) uint64 {
+	r := uint64(1)
+	b %= m
+	for e > 0 {
+		if e&1 == 1 {
+			r = mulMod(r, b, m)
+		}
+		b = mulMod(b, b, m)
+		e >>= 1
+	}
+	return r
+}
+
+// IsPrime64 reports whether n is prime. The result is exact for every uint64.
+func IsPrime64(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range smallPrimes {
+		if n == p {
+			return true
+		}
+		if n%p == 0 {
+			return false
+		}
+	}
+	if n < trialLimit*trialLimit {
+		return true
+	}
+
+	d := n - 1
+	s := bits.TrailingZeros64(d)
+	d >>= uint(s)
+	// The first 12 bases are sufficient for n < 2^64.
+	for _, a := range mrBases[:12] {
+		x := powMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for r := 1; r < s; r++ {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPrimeBig reports whether n is prime.
+// The result is exact for n < 3.3 * 10^24. Larger numbers additionally go
+// through the Baillie-PSW test, which has no known counterexamples.
+func IsPrimeBig(n *big.Int) bool {
+	if n.Sign() <= 0 {
+		return false
+	}
+	if n.IsUint64() {
+		return IsPrime64(n.Uint64())
+	}
+	var r big.Int
+	for _, p := range smallPrimes {
+		if r.Mod(n, r.SetUint64(p)).Sign() == 0 {
+			return false
+		}
+	}
+
+	one := big.NewInt(1)
+	nm1 := new(big.Int).Sub(n, one)
+	d := new(big.Int).Set(nm1)
+	s := d.TrailingZeroBits()
+	d.Rsh(d, s)
+
+	var a, x big.Int
+	for _, base := range mrBases {
+		x.Exp(a.SetUint64(base), d, n)
+		if x.Cmp(one) == 0 || x.Cmp(nm1) == 0 {
+			continue
+		}
+		composite := true
+		for i := uint(1); i < s; i++ {
+			x.Mul(&x, &x).Mod(&x, n)
+			if x.Cmp(nm1) == 0 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	if n.Cmp(mrBigLimit) < 0 {
+		return true
+	}
+	return n.ProbablyPrime(0)
+}
+
+// IsPrime reports whether digits interpreted in radix is prime.
+func IsPrime(digits string, radix int) (bool, error) {
+	if digits == "" {
+		return false, fmt.Errorf("%w: empty", ErrInvalidNumber)
+	}
+	if n, err := strconv.ParseUint(digits, radix, 64); err == nil {
+		return IsPrime64(n), nil
+	} else if !errors.Is(err, strconv.ErrRange) {
+		return false, fmt.Errorf("%w: %q in radix %d", ErrInvalidNumber, digits, radix)
+	}
+	n, ok := new(big.Int).SetString(digits, radix)
+	if !ok {
+		return false, fmt.Errorf("%w: %q in radix %d", ErrInvalidNumber, digits, radix)
+	}
+	return IsPrimeBig(n), nil
+}
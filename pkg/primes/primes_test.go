@@ -0,0 +1,119 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package primes
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrimes_SmallNumbers(t *testing.T) {
+	t.Parallel()
+	const n = 200000
+	expected := make([]bool, n)
+	for _, p := range sieve(n) {
+		expected[p] = true
+	}
+	for i := 0; i < n; i++ {
+		if IsPrime64(uint64(i)) != expected[i] {
+			t.Fatalf("IsPrime64(%d) = %v", i, !expected[i])
+		}
+	}
+}
+
+func TestPrimes_IsPrime(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		digits   string
+		radix    int
+		expected bool
+	}{
+		{"2", 10, true},
+		{"1", 10, false},
+		{"0", 10, false},
+		// Carmichael numbers.
+		{"561", 10, false},
+		{"41041", 10, false},
+		// Strong pseudoprimes to base 2.
+		{"2047", 10, false},
+		{"3215031751", 10, false},
+		// Strong pseudoprime to bases 2..37.
+		{"3825123056546413051", 10, false},
+		{"18446744073709551557", 10, true}, // largest prime below 2^64
+		{"18446744073709551615", 10, false},
+		{"18446744073709551629", 10, true}, // smallest prime above 2^64
+		// The largest palindromic primes in full_results/largest_primes.txt.
+		{"9609457639843489367549069", 10, true},
+		{"7331530558321238550351337", 10, true},
+		{"9609457639843489367549067", 10, false},
+		// Mersenne primes.
+		{"170141183460469231731687303715884105727", 10, true},
+		{"618970019642690137449562111", 10, true},
+		{"618970019642690137449562113", 10, false},
+		// Hexadecimal.
+		{"b", 16, true},
+		{"f", 16, false},
+		{"7fffffff", 16, true},
+		{"7fffffffffffffffffffffffffffffff", 16, true},
+		{"ffffffffffffffffffffffffffffffff", 16, false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%s Radix %d", tc.digits, tc.radix), func(t *testing.T) {
+			t.Parallel()
+			res, err := IsPrime(tc.digits, tc.radix)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, res)
+			}
+			n, _ := new(big.Int).SetString(tc.digits, tc.radix)
+			assert.Equal(t, n.ProbablyPrime(20), res)
+		})
+	}
+
+	for _, s := range []string{"", "12a", "-7", "+7", "99999999999999999999999999x"} {
+		_, err := IsPrime(s, 10)
+		assert.ErrorIs(t, err, ErrInvalidNumber, s)
+	}
+	_, err := IsPrime("7", 37)
+	assert.ErrorIs(t, err, ErrInvalidNumber)
+}
+
+func TestPrimes_IsPrimeBig(t *testing.T) {
+	t.Parallel()
+	assert.False(t, IsPrimeBig(big.NewInt(-7)))
+	assert.False(t, IsPrimeBig(big.NewInt(0)))
+	assert.True(t, IsPrimeBig(big.NewInt(7)))
+
+	// Products of two primes just above 2^64 must be composite.
+	p, _ := new(big.Int).SetString("18446744073709551629", 10)
+	q, _ := new(big.Int).SetString("18446744073709551653", 10)
+	assert.False(t, IsPrimeBig(new(big.Int).Mul(p, q)))
+	assert.True(t, IsPrimeBig(q))
+}
+
+func BenchmarkIsPrime64(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		IsPrime64(18446744073709551557)
+	}
+}
+
+func BenchmarkIsPrime25Digits(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		IsPrime("9609457639843489367549069", 10)
+	}
+}
@@ -32,6 +32,8 @@ type Reader struct {
 	off    int64
 	rd     io.ReadCloser
 	seeked bool
+	// start is the offset rd was created at.
+	start int64
 }
 
 // Reader implements both io.ReaderAt and io.ReadSeekCloser
@@ -50,16 +52,25 @@ func readOnce(set ResultSet, bucket obj.Bucket, p []byte, off int64) (int, error
 
 // ReadAt reads len(p) bytes of packed digits starting at byte result offset
 // (first byte in the result set is 0).
-// Returns io.EOF at the end of the result set.
+// Returns io.EOF at the end of the result set and io.ErrUnexpectedEOF if an
+// object ends before the digits of its block.
 func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
 	n := 0
 
 	for n < len(p) {
 		read, err := readOnce(r.set, r.bucket, p[n:], off+int64(n))
 		n += read
-		if err == io.ErrUnexpectedEOF {
+		// The range ended before p was filled, at the end of a block or
+		// because the response was cut short. Continue from there.
+		if err == io.ErrUnexpectedEOF && read > 0 {
 			continue
 		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if r.set.holdsDigits(off + int64(n)) {
+				return n, io.ErrUnexpectedEOF
+			}
+			return n, io.EOF
+		}
 		if err != nil {
 			return n, err
 		}
@@ -77,7 +88,11 @@ func (r *Reader) Read(p []byte) (int, error) {
 		}
 		reader, err := newRangeReader(context.Background(), r.set, r.bucket, r.off, -1)
 		r.rd = reader
+		r.start = r.off
 		r.seeked = false
+		if err == io.EOF && r.set.holdsDigits(r.off) {
+			return 0, io.ErrUnexpectedEOF
+		}
 		if err != nil {
 			return 0, err
 		}
@@ -87,6 +102,10 @@ func (r *Reader) Read(p []byte) (int, error) {
 	if err == io.EOF {
 		// Next Read() call needs to recreate the reader.
 		r.seeked = true
+		// A reader returning nothing would be recreated forever.
+		if r.off == r.start && r.set.holdsDigits(r.off) {
+			return n, io.ErrUnexpectedEOF
+		}
 		// Ignore EOF because there might be more data.
 		return n, nil
 	}
@@ -15,14 +15,12 @@
 package resultset_test
 
 import (
-	"bytes"
 	"context"
 	"io"
 	"testing"
 	"testing/iotest"
 
-	"github.com/golang/mock/gomock"
-	mock_obj "github.com/googlecloudplatform/pi-delivery/pkg/obj/mocks"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
 	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
 	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
 	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
@@ -40,7 +38,6 @@ func genTestByteSeq(n int) []byte {
 
 func TestResultSet_ReadAt(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
 	testSet := resultset.ResultSet{
 		{
@@ -67,11 +64,11 @@ func TestResultSet_ReadAt(t *testing.T) {
 		},
 	}
 	ctx := context.Background()
+	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
 
-	bucket := mock_obj.NewMockBucket(mockCtrl)
-	object := mock_obj.NewMockObject(mockCtrl)
-
-	reader := testSet.NewReader(ctx, bucket)
+	client := memory.NewClient()
+	tests.PutResultSet(client, "bucket", testSet, testBuf)
+	reader := testSet.NewReader(ctx, client.Bucket("bucket"))
 	require.NotNil(t, reader)
 	defer assert.NoError(t, reader.Close())
 
@@ -82,18 +79,8 @@ func TestResultSet_ReadAt(t *testing.T) {
 	assert.Zero(t, n)
 	assert.NoError(t, err)
 
-	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
-
 	// Simple read from the start
 	buf := make([]byte, 8)
-	bucket.EXPECT().
-		Object(testSet[0].Name).
-		Return(object)
-
-	object.EXPECT().
-		NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset), int64(len(buf))).
-		Return(io.NopCloser(bytes.NewReader(testBuf[:48])), nil)
-
 	n, err = reader.ReadAt(buf, 0)
 	assert.Equal(t, len(buf), n)
 	assert.NoError(t, err)
@@ -101,14 +88,6 @@ func TestResultSet_ReadAt(t *testing.T) {
 
 	// Simple read with offset
 	buf = make([]byte, 16)
-	bucket.EXPECT().
-		Object(testSet[0].Name).
-		Return(object)
-
-	object.EXPECT().
-		NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset+16), int64(len(buf))).
-		Return(io.NopCloser(bytes.NewReader(testBuf[16:])), nil)
-
 	n, err = reader.ReadAt(buf, 16)
 	assert.Equal(t, len(buf), n)
 	assert.NoError(t, err)
@@ -116,24 +95,6 @@ func TestResultSet_ReadAt(t *testing.T) {
 
 	// Reading across object boundaries
 	buf = make([]byte, 24)
-	gomock.InOrder(
-		bucket.EXPECT().
-			Object(testSet[0].Name).
-			Return(object),
-		bucket.EXPECT().
-			Object(testSet[1].Name).
-			Return(object),
-	)
-
-	gomock.InOrder(
-		object.EXPECT().
-			NewRangeReader(ctx, int64(testSet[0].FirstDigitOffset+40), int64(8)).
-			Return(io.NopCloser(bytes.NewReader(testBuf[40:48])), nil),
-		object.EXPECT().
-			NewRangeReader(ctx, int64(testSet[1].FirstDigitOffset), int64(len(buf)-8)).
-			Return(io.NopCloser(bytes.NewReader(testBuf[48:])), nil),
-	)
-
 	n, err = reader.ReadAt(buf, 40)
 	assert.Equal(t, len(buf), n)
 	assert.NoError(t, err)
@@ -141,22 +102,22 @@ func TestResultSet_ReadAt(t *testing.T) {
 
 	// Reading past EOF
 	buf = make([]byte, 16)
-	bucket.EXPECT().
-		Object(testSet[1].Name).
-		Return(object)
-	object.EXPECT().
-		NewRangeReader(ctx, int64(testSet[1].FirstDigitOffset+40), int64(8)).
-		Return(io.NopCloser(bytes.NewReader(testBuf[88:])), nil)
-
 	n, err = reader.ReadAt(buf, 88)
 	assert.Equal(t, 8, n)
 	assert.ErrorIs(t, err, io.EOF)
 	assert.Equal(t, append(testBuf[88:], make([]byte, 8)...), buf)
+
+	// Short reads from the objects
+	client.SetFaults(memory.Faults{ReadSize: 3})
+	buf = make([]byte, 24)
+	n, err = reader.ReadAt(buf, 40)
+	assert.Equal(t, len(buf), n)
+	assert.NoError(t, err)
+	assert.Equal(t, testBuf[40:40+len(buf)], buf)
 }
 
 func TestResultSet_IOTest(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
 	testSet := resultset.ResultSet{
 		{
@@ -190,34 +151,10 @@ func TestResultSet_IOTest(t *testing.T) {
 	ctx := context.Background()
 	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
 
-	bucket := mock_obj.NewMockBucket(mockCtrl)
-	obj0 := mock_obj.NewMockObject(mockCtrl)
-	obj1 := mock_obj.NewMockObject(mockCtrl)
-
-	bucket.EXPECT().Object(testSet[0].Name).Return(obj0).AnyTimes()
-	bucket.EXPECT().Object(testSet[1].Name).Return(obj1).AnyTimes()
+	client := memory.NewClient()
+	tests.PutResultSet(client, "bucket", testSet, testBuf)
 
-	obj0.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 0, testBuf, off, length)
-		},
-	).AnyTimes()
-
-	obj1.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 1, testBuf, off, length)
-		},
-	).AnyTimes()
-
-	reader := testSet.NewReader(ctx, bucket)
+	reader := testSet.NewReader(ctx, client.Bucket("bucket"))
 	require.NotNil(t, reader)
 	defer assert.NoError(t, reader.Close())
 
@@ -227,7 +164,6 @@ func TestResultSet_IOTest(t *testing.T) {
 
 func TestResultSet_PartialBlock(t *testing.T) {
 	t.Parallel()
-	mockCtrl := gomock.NewController(t)
 
 	testSet := resultset.ResultSet{
 		{
@@ -261,34 +197,10 @@ func TestResultSet_PartialBlock(t *testing.T) {
 	ctx := context.Background()
 	testBuf := genTestByteSeq(int(testSet.TotalByteLength()))
 
-	bucket := mock_obj.NewMockBucket(mockCtrl)
-	obj0 := mock_obj.NewMockObject(mockCtrl)
-	obj1 := mock_obj.NewMockObject(mockCtrl)
-
-	bucket.EXPECT().Object(testSet[0].Name).Return(obj0).AnyTimes()
-	bucket.EXPECT().Object(testSet[1].Name).Return(obj1).AnyTimes()
-
-	obj0.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 0, testBuf, off, length)
-		},
-	).AnyTimes()
-
-	obj1.EXPECT().NewRangeReader(
-		gomock.AssignableToTypeOf(ctx),
-		gomock.Any(),
-		gomock.Any(),
-	).DoAndReturn(
-		func(ctx context.Context, off, length int64) (io.ReadCloser, error) {
-			return tests.NewTestReader(testSet, 1, testBuf, off, length)
-		},
-	).AnyTimes()
+	client := memory.NewClient()
+	tests.PutResultSet(client, "bucket", testSet, testBuf)
 
-	reader := testSet.NewReader(ctx, bucket)
+	reader := testSet.NewReader(ctx, client.Bucket("bucket"))
 	require.NotNil(t, reader)
 	defer assert.NoError(t, reader.Close())
 
@@ -17,7 +17,9 @@ package resultset
 import (
 	"context"
 	"io"
+	"path"
 	"sort"
+	"strings"
 
 	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
 	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
@@ -116,12 +118,67 @@ func (s ResultSet) Radix() int {
 	return s[0].Header.Radix
 }
 
-// FirstDigit returns the first digit of the result.
+// IntegerPart returns the digits before the radix point, e.g. "3" for pi and
+// "14" for sqrt(200). The ycd files only hold the digits after the point.
+func (s ResultSet) IntegerPart() string {
+	if len(s) == 0 {
+		return ""
+	}
+	first := s[0].Header.FirstDigits
+	if i := strings.IndexByte(first, '.'); i >= 0 {
+		return first[:i]
+	}
+	return first
+}
+
+// Position returns the position of the digit at offset off after the point
+// as numbered by service.Get and api.pi.delivery, where the first digit of
+// the integer part is 0, e.g. off+1 for pi and off+2 for sqrt(200).
+func (s ResultSet) Position(off int64) int64 {
+	return int64(len(s.IntegerPart())) + off
+}
+
+// FirstDigit returns the first digit of the integer part of the result.
 func (s ResultSet) FirstDigit() byte {
+	if ip := s.IntegerPart(); ip != "" {
+		return ip[0]
+	}
+	return 0
+}
+
+// Constant returns an identifier of the constant computed, derived from the
+// names y-cruncher gives to the files: the lowercase letters and digits before
+// the first " - ", e.g. "pi" for "Pi - Dec - Chudnovsky - 0.ycd", "e" for
+// "e - Dec - exp(1) - 0.ycd" and "sqrt2" for "Sqrt(2) - Dec - 0.ycd".
+func (s ResultSet) Constant() string {
 	if len(s) == 0 {
-		return 0
+		return ""
+	}
+	name := path.Base(s[0].Name)
+	if i := strings.Index(name, " - "); i >= 0 {
+		name = name[:i]
+	} else {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// holdsDigits reports whether the byte offset off is within the packed digits
+// of a block. Objects ending before such an offset are truncated.
+// The last block holds fewer digits than the block size if TotalDigits is set.
+func (s ResultSet) holdsDigits(off int64) bool {
+	if off < 0 || off >= s.TotalByteLength() {
+		return false
 	}
-	return s[0].Header.FirstDigits[0]
+	block, blockOff := s.OffsetToBlockPos(off)
+	dpw := int64(s.DigitsPerWord())
+	return blockOff < (s[block].Header.BlockDigits()+dpw-1)/dpw*ycd.WordSize
 }
 
 // newRangeReader returns a io.ReadCloser for section [off, off+length) in the resultset.
@@ -102,6 +102,8 @@ func TestResultSet_Decimal(t *testing.T) {
 	assert.Equal(t, 19, testSet.DigitsPerWord())
 	assert.Equal(t, 10, testSet.Radix())
 	assert.Equal(t, byte('3'), testSet.FirstDigit())
+	assert.Equal(t, "3", testSet.IntegerPart())
+	assert.Equal(t, "pi", testSet.Constant())
 
 	testCases := []struct {
 		off, expectedId, expectedOff int64
@@ -193,6 +195,43 @@ func TestResultSet_Hexadecimal(t *testing.T) {
 	}
 }
 
+func TestResultSet_Constant(t *testing.T) {
+	t.Parallel()
+	newSet := func(name, firstDigits string) resultset.ResultSet {
+		return resultset.ResultSet{
+			{
+				Header: &ycd.Header{FirstDigits: firstDigits},
+				Name:   name,
+			},
+		}
+	}
+	testCases := []struct {
+		name, firstDigits string
+		constant, integer string
+		first             byte
+		// position is the position of the digit at offset 10.
+		position int64
+	}{
+		{"e - Dec - exp(1)/e - Dec - exp(1) - 0.ycd", "2.71828182845904523536", "e", "2", '2', 11},
+		{"Sqrt(200) - Dec - Newton's Method - 3.ycd", "14.1421356237309504880", "sqrt200", "14", '1', 12},
+		{"Zeta(3) - Hex - Wedeniwski/Zeta(3) - Hex - Wedeniwski - 0.ycd", "1.33bba004ee0f", "zeta3", "1", '1', 11},
+		{"digits.ycd", "1234", "digits", "1234", '1', 14},
+		{"", "", "", "", 0, 10},
+	}
+	for _, tc := range testCases {
+		set := newSet(tc.name, tc.firstDigits)
+		assert.Equal(t, tc.constant, set.Constant(), tc.name)
+		assert.Equal(t, tc.integer, set.IntegerPart(), tc.name)
+		assert.Equal(t, tc.first, set.FirstDigit(), tc.name)
+		assert.Equal(t, tc.position, set.Position(10), tc.name)
+	}
+
+	var empty resultset.ResultSet
+	assert.Empty(t, empty.Constant())
+	assert.Empty(t, empty.IntegerPart())
+	assert.Zero(t, empty.FirstDigit())
+}
+
 func TestResultSet_DecimalPartialBlock(t *testing.T) {
 	t.Parallel()
 	testSet := resultset.ResultSet{
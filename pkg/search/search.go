@@ -0,0 +1,180 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package search finds digit strings in a stream of unpacked digits.
+package search
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+)
+
+var ErrInvalidPattern = errors.New("search: invalid pattern")
+
+// DefaultBufferSize is the number of digits read from the stream at a time.
+const DefaultBufferSize = 1 << 20
+
+// maxRadix is the largest radix supported by Matcher.
+const maxRadix = 16
+
+// Occurrence is a match of a pattern.
+type Occurrence struct {
+	// Pattern is the index of the pattern passed to NewMatcher.
+	Pattern int
+	// Pos is the digit offset of the first digit of the match
+	// (0 is the first digit after the decimal point).
+	// Use ResultSet.Position to number it as service.Get does.
+	Pos int64
+}
+
+// Matcher is an Aho-Corasick automaton matching a set of patterns at once.
+// The automaton state is kept across reads so occurrences spanning two
+// reads of the stream are found.
+// A Matcher is safe for concurrent use by multiple goroutines.
+type Matcher struct {
+	patterns []string
+	radix    int
+	// next is the transition table indexed by state and digit value.
+	next [][maxRadix]int32
+	// out lists the patterns ending at each state.
+	out [][]int32
+}
+
+// NewMatcher returns a Matcher for patterns of digits in radix (at most 16).
+// Hexadecimal patterns are case insensitive.
+func NewMatcher(patterns []string, radix int) (*Matcher, error) {
+	if radix < 2 || radix > maxRadix {
+		return nil, fmt.Errorf("%w: unsupported radix %d", ErrInvalidPattern, radix)
+	}
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("%w: no patterns", ErrInvalidPattern)
+	}
+	m := &Matcher{
+		patterns: make([]string, len(patterns)),
+		radix:    radix,
+		next:     make([][maxRadix]int32, 1),
+		out:      make([][]int32, 1),
+	}
+	// Build the trie. 0 is both the root and "no transition" as no edge
+	// leads back to the root in a trie.
+	for i, p := range patterns {
+		p = strings.ToLower(p)
+		if p == "" {
+			return nil, fmt.Errorf("%w: empty pattern", ErrInvalidPattern)
+		}
+		m.patterns[i] = p
+		s := int32(0)
+		for j := 0; j < len(p); j++ {
+			v := unpack.DigitValue(p[j], radix)
+			if v < 0 {
+				return nil, fmt.Errorf("%w: %q in radix %d", ErrInvalidPattern, patterns[i], radix)
+			}
+			if m.next[s][v] == 0 {
+				m.next = append(m.next, [maxRadix]int32{})
+				m.out = append(m.out, nil)
+				m.next[s][v] = int32(len(m.next) - 1)
+			}
+			s = m.next[s][v]
+		}
+		m.out[s] = append(m.out[s], int32(i))
+	}
+
+	// Compute the failure links in breadth first order and turn the trie into
+	// a complete transition table.
+	fail := make([]int32, len(m.next))
+	queue := make([]int32, 0, len(m.next))
+	for v := 0; v < radix; v++ {
+		if c := m.next[0][v]; c != 0 {
+			queue = append(queue, c)
+		}
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		m.out[s] = append(m.out[s], m.out[fail[s]]...)
+		for v := 0; v < radix; v++ {
+			c := m.next[s][v]
+			if c == 0 {
+				m.next[s][v] = m.next[fail[s]][v]
+				continue
+			}
+			fail[c] = m.next[fail[s]][v]
+			queue = append(queue, c)
+		}
+	}
+	return m, nil
+}
+
+// Patterns returns the normalized patterns of m.
+func (m *Matcher) Patterns() []string {
+	return m.patterns
+}
+
+// Scan reads digits from rd until io.EOF and calls fn for every occurrence
+// in the order their last digits are read, longest first for occurrences
+// ending at the same digit. off is the digit offset of the
+// first digit read from rd, e.g. where an unpack.UnpackReader was seeked to.
+// Scanning stops early if fn returns false.
+func (m *Matcher) Scan(rd io.Reader, off int64, fn func(Occurrence) bool) error {
+	buf := make([]byte, DefaultBufferSize)
+	s := int32(0)
+	for {
+		n, err := rd.Read(buf)
+		for i, c := range buf[:n] {
+			v := unpack.DigitValue(c, m.radix)
+			if v < 0 {
+				return fmt.Errorf("search: invalid digit %q at offset %d", c, off+int64(i))
+			}
+			s = m.next[s][v]
+			for _, p := range m.out[s] {
+				pos := off + int64(i) - int64(len(m.patterns[p])) + 1
+				if !fn(Occurrence{Pattern: int(p), Pos: pos}) {
+					return nil
+				}
+			}
+		}
+		off += int64(n)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// FirstN returns the first n occurrences of every pattern read from rd,
+// indexed by pattern. It stops reading as soon as every pattern is found
+// n times. off is the digit offset of the first digit read from rd.
+func (m *Matcher) FirstN(rd io.Reader, off int64, n int) ([][]Occurrence, error) {
+	res := make([][]Occurrence, len(m.patterns))
+	if n <= 0 {
+		return res, nil
+	}
+	remaining := len(m.patterns)
+	err := m.Scan(rd, off, func(o Occurrence) bool {
+		if len(res[o.Pattern]) < n {
+			res[o.Pattern] = append(res[o.Pattern], o)
+			if len(res[o.Pattern]) == n {
+				remaining--
+			}
+		}
+		return remaining > 0
+	})
+	return res, err
+}
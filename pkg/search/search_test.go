@@ -0,0 +1,178 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package search
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// piDigits are the first 800 digits of pi after the decimal point.
+const piDigits = "14159265358979323846264338327950288419716939937510" +
+	"58209749445923078164062862089986280348253421170679" +
+	"82148086513282306647093844609550582231725359408128" +
+	"48111745028410270193852110555964462294895493038196" +
+	"44288109756659334461284756482337867831652712019091" +
+	"45648566923460348610454326648213393607260249141273" +
+	"72458700660631558817488152092096282925409171536436" +
+	"78925903600113305305488204665213841469519415116094" +
+	"33057270365759591953092186117381932611793105118548" +
+	"07446237996274956735188575272489122793818301194912" +
+	"98336733624406566430860213949463952247371907021798" +
+	"60943702770539217176293176752384674818467669405132" +
+	"00056812714526356082778577134275778960917363717872" +
+	"14684409012249534301465495853710507922796892589235" +
+	"42019956112129021960864034418159813629774771309960" +
+	"51870721134999999837297804995105973173281609631859"
+
+// naiveFind returns every occurrence of the patterns in digits, ordered like Scan.
+func naiveFind(patterns []string, digits string) []Occurrence {
+	var res []Occurrence
+	for end := 1; end <= len(digits); end++ {
+		var found []Occurrence
+		for i, p := range patterns {
+			if strings.HasSuffix(digits[:end], p) {
+				found = append(found, Occurrence{Pattern: i, Pos: int64(end - len(p))})
+			}
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].Pos < found[j].Pos })
+		res = append(res, found...)
+	}
+	return res
+}
+
+func scanAll(t *testing.T, m *Matcher, rd interface{ Read([]byte) (int, error) }, off int64) []Occurrence {
+	t.Helper()
+	var res []Occurrence
+	require.NoError(t, m.Scan(rd, off, func(o Occurrence) bool {
+		res = append(res, o)
+		return true
+	}))
+	return res
+}
+
+func TestSearch_Scan(t *testing.T) {
+	t.Parallel()
+	patterns := []string{"999999", "99", "9", "26", "14159", "314", "0", "999999"}
+	m, err := NewMatcher(patterns, 10)
+	require.NoError(t, err)
+	expected := naiveFind(patterns, piDigits)
+
+	assert.Equal(t, expected, scanAll(t, m, strings.NewReader(piDigits), 0))
+	// Occurrences crossing reads.
+	assert.Equal(t, expected, scanAll(t, m, iotest.OneByteReader(strings.NewReader(piDigits)), 0))
+	assert.Equal(t, expected, scanAll(t, m, iotest.HalfReader(strings.NewReader(piDigits)), 0))
+	assert.Equal(t, expected, scanAll(t, m, iotest.DataErrReader(strings.NewReader(piDigits)), 0))
+
+	// Offsets are absolute.
+	res := scanAll(t, m, strings.NewReader(piDigits[700:]), 700)
+	var feynman []int64
+	for _, o := range res {
+		if o.Pattern == 0 {
+			feynman = append(feynman, o.Pos)
+		}
+	}
+	assert.Equal(t, []int64{761}, feynman)
+}
+
+func TestSearch_Random(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	digits := make([]byte, 20000)
+	for i := range digits {
+		digits[i] = "0123"[rnd.Intn(4)]
+	}
+	var patterns []string
+	for i := 0; i < 50; i++ {
+		p := make([]byte, 1+rnd.Intn(8))
+		for j := range p {
+			p[j] = "0123"[rnd.Intn(4)]
+		}
+		patterns = append(patterns, string(p))
+	}
+	m, err := NewMatcher(patterns, 10)
+	require.NoError(t, err)
+	assert.Equal(t, naiveFind(patterns, string(digits)), scanAll(t, m, iotest.HalfReader(strings.NewReader(string(digits))), 0))
+}
+
+func TestSearch_Hexadecimal(t *testing.T) {
+	t.Parallel()
+	m, err := NewMatcher([]string{"A3", "243f", "8"}, 16)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a3", "243f", "8"}, m.Patterns())
+	res, err := m.FirstN(strings.NewReader("243f6a8885a308d313198a2e"), 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, [][]Occurrence{
+		{{Pattern: 0, Pos: 10}},
+		{{Pattern: 1, Pos: 0}},
+		{{Pattern: 2, Pos: 6}, {Pattern: 2, Pos: 7}},
+	}, res)
+
+	_, err = m.FirstN(strings.NewReader("243f6a88x"), 0, 5)
+	assert.Error(t, err)
+}
+
+func TestSearch_FirstN(t *testing.T) {
+	t.Parallel()
+	m, err := NewMatcher([]string{"26", "999999", "1234567890"}, 10)
+	require.NoError(t, err)
+	res, err := m.FirstN(strings.NewReader(piDigits), 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []Occurrence{{0, 5}, {0, 20}, {0, 274}}, res[0])
+	assert.Equal(t, []Occurrence{{1, 761}}, res[1])
+	assert.Empty(t, res[2])
+
+	// Reading stops once every pattern is found.
+	m, err = NewMatcher([]string{"1", "5"}, 10)
+	require.NoError(t, err)
+	rd := strings.NewReader(piDigits)
+	res, err = m.FirstN(iotest.OneByteReader(rd), 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, [][]Occurrence{{{0, 0}, {0, 2}}, {{1, 3}, {1, 7}}}, res)
+	assert.Equal(t, len(piDigits)-8, rd.Len())
+}
+
+func TestSearch_Errors(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		patterns []string
+		radix    int
+	}{
+		{nil, 10},
+		{[]string{""}, 10},
+		{[]string{"12a"}, 10},
+		{[]string{"12g"}, 16},
+		{[]string{"-1"}, 10},
+		{[]string{"1"}, 17},
+	} {
+		_, err := NewMatcher(tc.patterns, tc.radix)
+		assert.ErrorIs(t, err, ErrInvalidPattern, tc.patterns)
+	}
+
+	m, err := NewMatcher([]string{"1"}, 10)
+	require.NoError(t, err)
+	ioErr := errors.New("I/O error")
+	err = m.Scan(iotest.TimeoutReader(strings.NewReader(piDigits)), 0, func(Occurrence) bool { return true })
+	assert.ErrorIs(t, err, iotest.ErrTimeout)
+	err = m.Scan(iotest.ErrReader(ioErr), 0, func(Occurrence) bool { return true })
+	assert.ErrorIs(t, err, ioErr)
+}
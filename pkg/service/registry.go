@@ -0,0 +1,113 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+)
+
+var ErrUnknownResultSet = errors.New("unknown result set")
+var ErrDuplicateResultSet = errors.New("result set already registered")
+var ErrUnsupportedRadix = errors.New("unsupported radix")
+
+// Entry is a result set registered by name.
+type Entry struct {
+	// Name is the registered name, e.g. pi-dec.
+	Name string
+	// Set is the result set.
+	Set resultset.ResultSet
+	// Bucket is the name of the bucket containing the files of Set.
+	Bucket string
+}
+
+// Registry maps names to result sets and the buckets storing them.
+// It is safe for concurrent use.
+type Registry struct {
+	lock    sync.RWMutex
+	entries map[string]*Entry
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		entries: make(map[string]*Entry),
+	}
+}
+
+// EntryName returns the conventional name for a result set of constant in radix,
+// e.g. EntryName("pi", 16) returns "pi-hex".
+func EntryName(constant string, radix int) string {
+	switch radix {
+	case 10:
+		return constant + "-dec"
+	case 16:
+		return constant + "-hex"
+	default:
+		return fmt.Sprintf("%s-%d", constant, radix)
+	}
+}
+
+// Register adds set stored in bucket as name.
+func (r *Registry) Register(name string, set resultset.ResultSet, bucket string) error {
+	if name == "" {
+		return errors.New("Register: empty name")
+	}
+	if len(set) == 0 {
+		return fmt.Errorf("Register %s: empty result set", name)
+	}
+	if radix := set.Radix(); radix != 10 && radix != 16 {
+		return fmt.Errorf("Register %s: %w: %d", name, ErrUnsupportedRadix, radix)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("Register %s: %w", name, ErrDuplicateResultSet)
+	}
+	r.entries[name] = &Entry{
+		Name:   name,
+		Set:    set,
+		Bucket: bucket,
+	}
+	return nil
+}
+
+// Lookup returns the entry registered as name.
+func (r *Registry) Lookup(name string) (*Entry, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	e, ok := r.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownResultSet, name)
+	}
+	return e, nil
+}
+
+// Names returns the sorted list of registered names.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for k := range r.entries {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
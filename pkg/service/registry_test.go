@@ -0,0 +1,146 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// pack packs digits into little endian words of radix.
+func pack(t *testing.T, digits string, radix int) []byte {
+	dpw := ycd.DigitsPerWord(radix)
+	buf := make([]byte, len(digits)/dpw*ycd.WordSize)
+	for i := 0; i < len(buf); i += ycd.WordSize {
+		word, err := strconv.ParseUint(digits[i/ycd.WordSize*dpw:][:dpw], radix, 64)
+		require.NoError(t, err)
+		binary.LittleEndian.PutUint64(buf[i:], word)
+	}
+	return buf
+}
+
+func newTestSet(name string, radix int, firstDigits string, blockSize int64) resultset.ResultSet {
+	return resultset.ResultSet{
+		{
+			Header: &ycd.Header{
+				FileVersion: "1.1.0",
+				Radix:       radix,
+				FirstDigits: firstDigits,
+				BlockSize:   blockSize,
+				BlockID:     int64(0),
+				Length:      198,
+			},
+			Name:             name,
+			FirstDigitOffset: 201,
+		},
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	dec := newTestSet("dec.ycd", 10, "3.1415926535897932384", 38)
+	hex := newTestSet("hex.ycd", 16, "3.243f6a8885a308d3", 32)
+
+	assert.NoError(t, r.Register("pi-dec", dec, "bucket"))
+	assert.NoError(t, r.Register("pi-hex", hex, "bucket"))
+	assert.ErrorIs(t, r.Register("pi-dec", dec, "bucket"), ErrDuplicateResultSet)
+	assert.Error(t, r.Register("", dec, "bucket"))
+	assert.Error(t, r.Register("empty", resultset.ResultSet{}, "bucket"))
+	assert.ErrorIs(t, r.Register("pi-oct", newTestSet("oct.ycd", 8, "3.11", 32), "bucket"), ErrUnsupportedRadix)
+
+	assert.Equal(t, []string{"pi-dec", "pi-hex"}, r.Names())
+
+	e, err := r.Lookup("pi-hex")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pi-hex", e.Name)
+		assert.Equal(t, hex, e.Set)
+		assert.Equal(t, "bucket", e.Bucket)
+	}
+	_, err = r.Lookup("e-dec")
+	assert.ErrorIs(t, err, ErrUnknownResultSet)
+
+	assert.Equal(t, "pi-dec", EntryName("pi", 10))
+	assert.Equal(t, "e-hex", EntryName("e", 16))
+}
+
+func TestService_GetByName(t *testing.T) {
+	t.Parallel()
+	const (
+		decDigits = "14159265358979323846264338327950288419"
+		hexDigits = "243f6a8885a308d313198a2e03707344"
+	)
+	ctx := context.Background()
+
+	dec := newTestSet("Pi - Dec/Pi - Dec - 0.ycd", 10, "3."+decDigits, int64(len(decDigits)))
+	hex := newTestSet("Pi - Hex/Pi - Hex - 0.ycd", 16, "3."+hexDigits, int64(len(hexDigits)))
+	decBuf := pack(t, decDigits, 10)
+	hexBuf := pack(t, hexDigits, 16)
+
+	client := memory.NewClient()
+	tests.PutResultSet(client, "dec", dec, decBuf)
+	tests.PutResultSet(client, "hex", hex, hexBuf)
+
+	l, _ := zap.NewDevelopment()
+	service := NewServiceWithClient(l.Sugar(), client, "default")
+	require.NoError(t, service.Register("pi-dec", dec, "dec"))
+	require.NoError(t, service.Register("pi-hex", hex, "hex"))
+
+	testCases := []struct {
+		name     string
+		start, n int64
+		expected string
+		err      error
+	}{
+		{"pi-dec", 0, 1, "3", nil},
+		{"pi-dec", 1, 5, "14159", nil},
+		{"pi-dec", 0, 39, "3" + decDigits, nil},
+		{"pi-dec", 30, 20, decDigits[29:], nil},
+		{"pi-dec", 38, 1, "9", nil},
+		{"pi-dec", 0, 0, "", nil},
+		{"pi-hex", 0, 5, "3243f", nil},
+		{"pi-hex", 17, 16, hexDigits[16:], nil},
+		{"pi-dec", 39, 1, "", ErrOutOfRange},
+		{"pi-dec", -1, 1, "", ErrInvalidArgument},
+		{"pi-dec", 0, -1, "", ErrInvalidArgument},
+		{"pi-dec", 0, MaxDigits + 1, "", ErrInvalidArgument},
+		{"e-dec", 0, 1, "", ErrUnknownResultSet},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("%s Start %d N %d", tc.name, tc.start, tc.n), func(t *testing.T) {
+			t.Parallel()
+			res, err := service.GetByName(ctx, tc.name, tc.start, tc.n)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, string(res))
+			}
+		})
+	}
+}
@@ -17,7 +17,9 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"sync"
 
 	"github.com/googlecloudplatform/pi-delivery/pkg/cached"
 	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
@@ -29,9 +31,28 @@ import (
 
 var errInternal = errors.New("internal error")
 
+var ErrInvalidArgument = errors.New("invalid argument")
+var ErrOutOfRange = errors.New("out of range")
+
+// MaxDigits is the maximum number of digits GetByName returns at once.
+const MaxDigits = 1000
+
 type Service struct {
-	storage obj.Client
-	bucket  obj.Bucket
+	storage    obj.Client
+	bucketName string
+	logger     *zap.SugaredLogger
+	registry   *Registry
+
+	lock   sync.Mutex
+	caches map[cacheKey]*cached.Cache
+}
+
+// cacheKey identifies a result set stored in a bucket. Sets sharing a first
+// file, e.g. a set and a prefix of it, have different keys.
+type cacheKey struct {
+	bucket      string
+	first, last string
+	n           int
 }
 
 func NewService(ctx context.Context, logger *zap.SugaredLogger, bucketName string) *Service {
@@ -40,39 +61,104 @@ func NewService(ctx context.Context, logger *zap.SugaredLogger, bucketName strin
 		logger.Fatalw("Failed to create a new Storage client",
 			"error", err)
 	}
+	return NewServiceWithClient(logger, storageClient, bucketName)
+}
+
+// NewServiceWithClient returns a new Service reading from client.
+// bucketName is the bucket used by Get. The service takes ownership of client.
+func NewServiceWithClient(logger *zap.SugaredLogger, client obj.Client, bucketName string) *Service {
 	return &Service{
-		storage: storageClient,
-		bucket:  storageClient.Bucket(bucketName),
+		storage:    client,
+		bucketName: bucketName,
+		logger:     logger,
+		registry:   NewRegistry(),
+		caches:     make(map[cacheKey]*cached.Cache),
 	}
 }
 
-// Get returns n bytes of pi starting at start.
-// The first digit (position 0) is 3 before the decimal point.
+// Register makes set stored in bucket available to GetByName as name.
+func (s *Service) Register(name string, set resultset.ResultSet, bucket string) error {
+	return s.registry.Register(name, set, bucket)
+}
+
+// Registry returns the registry of result sets served by GetByName.
+func (s *Service) Registry() *Registry {
+	return s.registry
+}
+
+// cache returns the cache for set stored in bucket, creating one if necessary.
+func (s *Service) cache(bucket string, set resultset.ResultSet) *cached.Cache {
+	key := cacheKey{bucket: bucket, n: len(set)}
+	if len(set) > 0 {
+		key.first, key.last = set[0].Name, set[len(set)-1].Name
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	c, ok := s.caches[key]
+	if !ok {
+		c = cached.NewCache(set, cached.DefaultCacheSize)
+		s.caches[key] = c
+	}
+	return c
+}
+
+// Get returns n digits of the constant of set starting at start.
+// Positions start with the integer part: position 0 is its first digit,
+// e.g. 3 for pi, and the digits after the radix point follow it.
 func (s *Service) Get(ctx context.Context, logger *zap.SugaredLogger, set resultset.ResultSet, start, n int64) ([]byte, error) {
+	return s.get(ctx, logger, set, s.bucketName, start, n)
+}
+
+// GetByName returns n digits of the result set registered as name starting at start.
+// Positions are numbered as in Get. n must be between 0 and MaxDigits and
+// start must be within the result set. The result is shorter than n if
+// the result set ends before start+n.
+func (s *Service) GetByName(ctx context.Context, name string, start, n int64) ([]byte, error) {
+	e, err := s.registry.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > MaxDigits {
+		return nil, fmt.Errorf("%w: number of digits must be between 0 and %d: %d",
+			ErrInvalidArgument, MaxDigits, n)
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("%w: negative start: %d", ErrInvalidArgument, start)
+	}
+	// Positions start with the integer part.
+	if last := int64(len(e.Set.IntegerPart())) + e.Set.TotalDigits() - 1; start > last {
+		return nil, fmt.Errorf("%w: start must be less than or equal to %d: %d",
+			ErrOutOfRange, last, start)
+	}
+	logger := s.logger.With("name", name)
+	return s.get(ctx, logger, e.Set, e.Bucket, start, n)
+}
+
+func (s *Service) get(ctx context.Context, logger *zap.SugaredLogger, set resultset.ResultSet, bucket string, start, n int64) ([]byte, error) {
 	logger = logger.With("start", start, "n", n)
 
 	if n == 0 {
 		return nil, nil
 	}
 
-	// pb.Range.Start counts at the first digit before the decimal point (3)
-	// while the rest of the program treats the first digit after the decimal point (1)
-	// as the zeroth digit. We need a special handling here.
-	zero := start == 0
+	// Positions count the digits of the integer part (3 for pi) first
+	// while the rest of the program treats the first digit after the radix
+	// point (1) as the zeroth digit. The integer part comes from the header.
 	unpacked := make([]byte, n)
-
 	off := 0
-	if zero {
-		n--
-		unpacked[0] = set.FirstDigit()
-		off = 1
+	if ip := set.IntegerPart(); start < int64(len(ip)) {
+		off = copy(unpacked, ip[start:])
+		start = 0
 	} else {
-		start--
+		start -= int64(len(ip))
+	}
+	if off == len(unpacked) {
+		return unpacked, nil
 	}
 
-	rr := set.NewReader(ctx, s.bucket)
+	rr := set.NewReader(ctx, s.storage.Bucket(bucket))
 	defer rr.Close()
-	reader := unpack.NewReader(ctx, cached.NewCachedReader(ctx, rr))
+	reader := unpack.NewReader(ctx, cached.NewCachedReader(ctx, s.cache(bucket, set), rr))
 	read, err := reader.ReadAt(unpacked[off:], start)
 
 	if err != nil && !errors.Is(err, io.EOF) {
@@ -81,11 +167,8 @@ func (s *Service) Get(ctx context.Context, logger *zap.SugaredLogger, set result
 		)
 		return nil, errInternal
 	}
-	if zero {
-		read++
-	}
 
-	return unpacked[:read], nil
+	return unpacked[:off+read], nil
 }
 
 // Close closes connections used by the service.
@@ -19,7 +19,7 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/googlecloudplatform/pi-delivery/gen/index"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -41,20 +41,28 @@ func TestService_SimpleGet(t *testing.T) {
 		{16, 1, 1, "2"},
 		{16, 0, 50, "3243f6a8885a308d313198a2e03707344a4093822299f31d00"},
 		{16, 1, 50, "243f6a8885a308d313198a2e03707344a4093822299f31d008"},
+		{10, 290, 20, "60249141273"},
+		{16, 240, 20, "6636920d871"},
 	}
 
+	// Blocks end in the middle of words and the last blocks are short.
+	dec, err := tests.NewFixture(tests.PiDec, 10, 45)
+	require.NoError(t, err)
+	hex, err := tests.NewFixture(tests.PiHex, 16, 40)
+	require.NoError(t, err)
+
 	l, _ := zap.NewDevelopment()
 	s := l.Sugar()
-	service := NewService(ctx, s, index.BucketName)
+	service := NewServiceWithClient(s, tests.NewClient(dec, hex), tests.FixtureBucket)
 	require.NotNil(t, service)
 
 	for _, tc := range testCases {
 		tc := tc
 		t.Run(fmt.Sprintf("Radix %d Start %d N %d", tc.radix, tc.start, tc.n), func(t *testing.T) {
 			t.Parallel()
-			set := index.Decimal
+			set := dec.Set
 			if tc.radix == 16 {
-				set = index.Hexadecimal
+				set = hex.Set
 			}
 			res, err := service.Get(ctx, s, set, tc.start, tc.n)
 			if assert.NoError(t, err) && assert.NotNil(t, res) {
@@ -63,3 +71,81 @@ func TestService_SimpleGet(t *testing.T) {
 		})
 	}
 }
+
+func TestService_IntegerPart(t *testing.T) {
+	t.Parallel()
+	const sqrt200 = "14." +
+		"1421356237309504880168872420969807856967187537694807317667973799" +
+		"07324784621070388503875343276415727350138462309122970249"
+	ctx := context.Background()
+	f, err := tests.NewConstantFixture("Sqrt(200)", sqrt200, 10, 40)
+	require.NoError(t, err)
+	require.Equal(t, "14", f.Set.IntegerPart())
+	require.Equal(t, "sqrt200", f.Set.Constant())
+
+	service := NewServiceWithClient(zap.NewNop().Sugar(), f.Client(), tests.FixtureBucket)
+	name := EntryName(f.Set.Constant(), f.Set.Radix())
+	require.NoError(t, service.Register(name, f.Set, tests.FixtureBucket))
+
+	testCases := []struct {
+		start, n int64
+		expected string
+	}{
+		{0, 1, "1"},
+		{0, 2, "14"},
+		{0, 5, "14142"},
+		{1, 3, "414"},
+		{2, 3, "142"},
+		{38, 6, "696718"},
+		{121, 1, "9"},
+		{118, 10, "0249"},
+	}
+	for _, tc := range testCases {
+		res, err := service.GetByName(ctx, name, tc.start, tc.n)
+		if assert.NoError(t, err, "start %d n %d", tc.start, tc.n) {
+			assert.Equal(t, tc.expected, string(res), "start %d n %d", tc.start, tc.n)
+		}
+	}
+	_, err = service.GetByName(ctx, name, 122, 1)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+}
+
+func TestService_SharedFiles(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f, err := tests.NewFixture(tests.PiDec, 10, 45)
+	require.NoError(t, err)
+	// The mirror bucket holds the same files but the last digit of the
+	// first word, position 19, is 5 instead of 4.
+	client := f.Client()
+	for name, data := range f.Files {
+		data = append([]byte(nil), data...)
+		if name == f.Set[0].Name {
+			data[f.Set[0].FirstDigitOffset]++
+		}
+		client.Put("mirror", name, data)
+	}
+	service := NewServiceWithClient(zap.NewNop().Sugar(), client, tests.FixtureBucket)
+	require.NoError(t, service.Register("pi", f.Set, tests.FixtureBucket))
+	require.NoError(t, service.Register("pi-prefix", f.Set[:2], tests.FixtureBucket))
+	require.NoError(t, service.Register("pi-mirror", f.Set, "mirror"))
+
+	for _, tc := range []struct {
+		name     string
+		start, n int64
+		expected string
+	}{
+		{"pi", 17, 4, "3846"},
+		{"pi-prefix", 17, 4, "3846"},
+		{"pi-mirror", 17, 4, "3856"},
+		{"pi", 60, 4, "4592"},
+		{"pi-prefix", 60, 4, "4592"},
+		{"pi-mirror", 60, 4, "4592"},
+	} {
+		res, err := service.GetByName(ctx, tc.name, tc.start, tc.n)
+		if assert.NoError(t, err, tc.name) {
+			assert.Equal(t, tc.expected, string(res), tc.name)
+		}
+	}
+	assert.Len(t, service.caches, 3)
+}
@@ -0,0 +1,175 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stats accumulates digit frequency statistics used to check the
+// normality of the digits.
+package stats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+)
+
+// MaxN is the length of the longest n-grams counted.
+const MaxN = 3
+
+// ErrInvalidDigit is returned for bytes that aren't digits in the radix.
+var ErrInvalidDigit = errors.New("stats: invalid digit")
+
+// Counts holds the number of occurrences of every digit and of every
+// n-gram (sequence of n consecutive digits) up to MaxN digits.
+// Counts of adjacent ranges can be merged.
+type Counts struct {
+	Radix int
+	// NGrams[n-1][v] is the number of n-grams whose digits read as the
+	// number v in Radix. NGrams[0] are the digit counts.
+	NGrams [MaxN][]int64
+}
+
+// New returns empty Counts for digits in radix (2 to 16).
+func New(radix int) *Counts {
+	if radix < 2 || radix > 16 {
+		panic(fmt.Sprintf("stats: unsupported radix %d", radix))
+	}
+	c := &Counts{Radix: radix}
+	size := 1
+	for n := range c.NGrams {
+		size *= radix
+		c.NGrams[n] = make([]int64, size)
+	}
+	return c
+}
+
+// Digits returns the number of digits counted.
+func (c *Counts) Digits() int64 {
+	var total int64
+	for _, v := range c.NGrams[0] {
+		total += v
+	}
+	return total
+}
+
+// Add counts the first n digits of digits and the n-grams starting at them.
+// The digits after the first n are only used to complete the n-grams, so
+// adjacent ranges are counted exactly once when the digits following a range
+// are passed along with it.
+// It returns ErrInvalidDigit without counting anything if any of the digits
+// used isn't a digit in c.Radix.
+func (c *Counts) Add(digits []byte, n int) error {
+	var v [MaxN]int
+	mod := [MaxN]int{}
+	for k := range mod {
+		mod[k] = len(c.NGrams[k])
+	}
+	end := n + MaxN - 1
+	if end > len(digits) {
+		end = len(digits)
+	}
+	for i := 0; i < end; i++ {
+		if unpack.DigitValue(digits[i], c.Radix) < 0 {
+			return fmt.Errorf("%w: %q at %d", ErrInvalidDigit, digits[i], i)
+		}
+	}
+	for i := 0; i < end; i++ {
+		d := unpack.DigitValue(digits[i], c.Radix)
+		// The k+1-gram ending at i starts at i-k.
+		for k := 0; k < MaxN; k++ {
+			v[k] = (v[k]*c.Radix + d) % mod[k]
+			if start := i - k; start >= 0 && start < n {
+				c.NGrams[k][v[k]]++
+			}
+		}
+	}
+	return nil
+}
+
+// Merge adds the counts of o to c.
+func (c *Counts) Merge(o *Counts) {
+	if c.Radix != o.Radix {
+		panic(fmt.Sprintf("stats: merging radix %d into radix %d", o.Radix, c.Radix))
+	}
+	for k := range c.NGrams {
+		for i, v := range o.NGrams[k] {
+			c.NGrams[k][i] += v
+		}
+	}
+}
+
+// Clone returns a copy of c.
+func (c *Counts) Clone() *Counts {
+	res := New(c.Radix)
+	res.Merge(c)
+	return res
+}
+
+// ChiSquare returns Pearson's chi-square statistic of the n-gram counts
+// against the uniform distribution expected from a normal number, along with
+// the degrees of freedom (Radix^n - 1).
+func (c *Counts) ChiSquare(n int) (chi2 float64, df int) {
+	counts := c.NGrams[n-1]
+	var total int64
+	for _, v := range counts {
+		total += v
+	}
+	df = len(counts) - 1
+	if total == 0 {
+		return 0, df
+	}
+	expected := float64(total) / float64(len(counts))
+	for _, v := range counts {
+		d := float64(v) - expected
+		chi2 += d * d / expected
+	}
+	return chi2, df
+}
+
+// ngram formats v as an n digit string in radix.
+func ngram(v, n, radix int) string {
+	s := strconv.FormatInt(int64(v), radix)
+	return strings.Repeat("0", n-len(s)) + s
+}
+
+// WriteReport writes the number of digits, the chi-square statistics and
+// the count and frequency of every n-gram.
+func (c *Counts) WriteReport(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "radix: %d\n", c.Radix)
+	fmt.Fprintf(bw, "digits: %d\n", c.Digits())
+	fmt.Fprintln(bw, "# n, chi-square, degrees of freedom")
+	for n := 1; n <= MaxN; n++ {
+		chi2, df := c.ChiSquare(n)
+		fmt.Fprintf(bw, "%d, %.4f, %d\n", n, chi2, df)
+	}
+	for n := 1; n <= MaxN; n++ {
+		var total int64
+		for _, v := range c.NGrams[n-1] {
+			total += v
+		}
+		fmt.Fprintf(bw, "# %d-grams: digits, count, frequency\n", n)
+		for v, count := range c.NGrams[n-1] {
+			freq := 0.0
+			if total > 0 {
+				freq = float64(count) / float64(total)
+			}
+			fmt.Fprintf(bw, "%s, %d, %.9f\n", ngram(v, n, c.Radix), count, freq)
+		}
+	}
+	return bw.Flush()
+}
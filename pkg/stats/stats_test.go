@@ -0,0 +1,185 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The first 100 digits of pi after the decimal point.
+const piDigits = "1415926535897932384626433832795028841971693993751058209749445923078164062862089986280348253421170679"
+
+func TestStats_Add(t *testing.T) {
+	t.Parallel()
+	c := New(10)
+	require.NoError(t, c.Add([]byte(piDigits), len(piDigits)))
+	assert.Equal(t, int64(100), c.Digits())
+	// Well known digit counts of the first 100 digits.
+	assert.Equal(t, []int64{8, 8, 12, 11, 10, 8, 9, 8, 12, 14}, c.NGrams[0])
+	assert.Equal(t, int64(1), c.NGrams[1][14])
+	assert.Equal(t, int64(4), c.NGrams[1][62])
+	assert.Equal(t, int64(1), c.NGrams[2][415])
+	var bigrams, trigrams int64
+	for i := range c.NGrams[1] {
+		bigrams += c.NGrams[1][i]
+	}
+	for i := range c.NGrams[2] {
+		trigrams += c.NGrams[2][i]
+	}
+	assert.Equal(t, int64(99), bigrams)
+	assert.Equal(t, int64(98), trigrams)
+
+	// Counting adjacent ranges with the following digits gives the same counts.
+	for _, size := range []int{1, 2, 3, 7, 50} {
+		split := New(10)
+		for i := 0; i < len(piDigits); i += size {
+			n := size
+			if i+n > len(piDigits) {
+				n = len(piDigits) - i
+			}
+			require.NoError(t, split.Add([]byte(piDigits[i:]), n))
+		}
+		assert.Equal(t, c, split, "size %d", size)
+	}
+
+	before := c.Clone()
+	assert.ErrorIs(t, c.Add([]byte("12a"), 3), ErrInvalidDigit)
+	// Digits past n complete the n-grams so they are checked too.
+	assert.ErrorIs(t, c.Add([]byte("12a"), 1), ErrInvalidDigit)
+	assert.Equal(t, before, c)
+	assert.Panics(t, func() { New(17) })
+}
+
+func TestStats_Hexadecimal(t *testing.T) {
+	t.Parallel()
+	c := New(16)
+	require.NoError(t, c.Add([]byte("243f6a8885a308d3"), 16))
+	assert.Equal(t, int64(16), c.Digits())
+	assert.Equal(t, int64(4), c.NGrams[0][8])
+	assert.Equal(t, int64(1), c.NGrams[0][0xf])
+	assert.Equal(t, int64(2), c.NGrams[1][0x88])
+	assert.Equal(t, int64(1), c.NGrams[2][0x888])
+	assert.Len(t, c.NGrams[2], 4096)
+}
+
+func TestStats_ChiSquare(t *testing.T) {
+	t.Parallel()
+	c := New(10)
+	chi2, df := c.ChiSquare(1)
+	assert.Equal(t, 0.0, chi2)
+	assert.Equal(t, 9, df)
+
+	// Every digit 10 times.
+	require.NoError(t, c.Add([]byte(strings.Repeat("0123456789", 10)), 100))
+	chi2, _ = c.ChiSquare(1)
+	assert.Equal(t, 0.0, chi2)
+	// Only 10 of the 100 2-grams occur ("90" once less): 0.99 expected each.
+	chi2, df = c.ChiSquare(2)
+	assert.InDelta(t, 9*(10-0.99)*(10-0.99)/0.99+(9-0.99)*(9-0.99)/0.99+90*0.99, chi2, 1e-9)
+	assert.Equal(t, 99, df)
+
+	c = New(10)
+	require.NoError(t, c.Add([]byte(piDigits), len(piDigits)))
+	chi2, _ = c.ChiSquare(1)
+	assert.InDelta(t, 4.2, chi2, 1e-9)
+}
+
+func TestStats_Report(t *testing.T) {
+	t.Parallel()
+	c := New(2)
+	require.NoError(t, c.Add([]byte("0110"), 4))
+	var buf bytes.Buffer
+	require.NoError(t, c.WriteReport(&buf))
+	assert.Equal(t, "radix: 2\n"+
+		"digits: 4\n"+
+		"# n, chi-square, degrees of freedom\n"+
+		"1, 0.0000, 1\n"+
+		"2, 1.0000, 3\n"+
+		"3, 6.0000, 7\n"+
+		"# 1-grams: digits, count, frequency\n"+
+		"0, 2, 0.500000000\n"+
+		"1, 2, 0.500000000\n"+
+		"# 2-grams: digits, count, frequency\n"+
+		"00, 0, 0.000000000\n"+
+		"01, 1, 0.333333333\n"+
+		"10, 1, 0.333333333\n"+
+		"11, 1, 0.333333333\n"+
+		"# 3-grams: digits, count, frequency\n"+
+		"000, 0, 0.000000000\n"+
+		"001, 0, 0.000000000\n"+
+		"010, 0, 0.000000000\n"+
+		"011, 1, 0.500000000\n"+
+		"100, 0, 0.000000000\n"+
+		"101, 0, 0.000000000\n"+
+		"110, 1, 0.500000000\n"+
+		"111, 0, 0.000000000\n",
+		buf.String())
+}
+
+func TestStats_Table(t *testing.T) {
+	t.Parallel()
+	whole := New(10)
+	require.NoError(t, whole.Add([]byte(piDigits), len(piDigits)))
+
+	// Chunks of 10 digits with blocks of 30 digits, counted by 4 workers.
+	global := NewTable(10, 30)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			local := NewTable(10, 30)
+			for i := w * 10; i < len(piDigits); i += 40 {
+				assert.NoError(t, local.Add(int64(i), []byte(piDigits[i:]), 10))
+			}
+			global.Merge(local)
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int64{0, 1, 2, 3}, global.Blocks())
+	assert.Equal(t, whole, global.Total())
+	assert.Equal(t, int64(30), global.Block(0).Digits())
+	assert.Equal(t, int64(10), global.Block(3).Digits())
+	assert.Equal(t, int64(0), global.Block(4).Digits())
+
+	block := New(10)
+	require.NoError(t, block.Add([]byte(piDigits[30:]), 30))
+	assert.Equal(t, block, global.Block(1))
+
+	// A range crossing block boundaries is split.
+	split := NewTable(10, 30)
+	require.NoError(t, split.Add(25, []byte(piDigits[25:]), 70))
+	assert.Equal(t, []int64{0, 1, 2, 3}, split.Blocks())
+	assert.Equal(t, int64(5), split.Block(0).Digits())
+	assert.Equal(t, block, split.Block(1))
+
+	split.Reset()
+	assert.Empty(t, split.Blocks())
+
+	// The error has the offset of the block with the invalid digit.
+	err := split.Add(25, []byte("12345"+"678x"), 9)
+	assert.ErrorIs(t, err, ErrInvalidDigit)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "digits at 30")
+	}
+	assert.Equal(t, []int64{0}, split.Blocks())
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Table accumulates Counts per block file of a result set.
+// It is safe for concurrent use.
+type Table struct {
+	radix     int
+	blockSize int64
+	lock      sync.Mutex
+	blocks    map[int64]*Counts
+}
+
+// NewTable returns an empty Table for digits in radix stored in blocks of
+// blockSize digits (resultset.ResultSet.BlockSize).
+func NewTable(radix int, blockSize int64) *Table {
+	if blockSize <= 0 {
+		panic("stats: block size must be positive")
+	}
+	return &Table{
+		radix:     radix,
+		blockSize: blockSize,
+		blocks:    make(map[int64]*Counts),
+	}
+}
+
+// Add counts the first n digits of digits, which start at digit offset off
+// (0 is the first digit after the decimal point). The digits are split
+// at block boundaries; see Counts.Add for the digits following the first n.
+// On error, the blocks before the one with the invalid digit are counted.
+func (t *Table) Add(off int64, digits []byte, n int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i := 0; i < n; {
+		block := (off + int64(i)) / t.blockSize
+		end := int((block+1)*t.blockSize - off)
+		if end > n {
+			end = n
+		}
+		c, ok := t.blocks[block]
+		if !ok {
+			c = New(t.radix)
+		}
+		if err := c.Add(digits[i:], end-i); err != nil {
+			return fmt.Errorf("digits at %d: %w", off+int64(i), err)
+		}
+		t.blocks[block] = c
+		i = end
+	}
+	return nil
+}
+
+// Merge adds the counts of o to t.
+func (t *Table) Merge(o *Table) {
+	o.lock.Lock()
+	blocks := make(map[int64]*Counts, len(o.blocks))
+	for id, c := range o.blocks {
+		blocks[id] = c.Clone()
+	}
+	o.lock.Unlock()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for id, c := range blocks {
+		if dst, ok := t.blocks[id]; ok {
+			dst.Merge(c)
+		} else {
+			t.blocks[id] = c
+		}
+	}
+}
+
+// Reset drops all counts.
+func (t *Table) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.blocks = make(map[int64]*Counts)
+}
+
+// Blocks returns the IDs of the blocks with counts in ascending order.
+func (t *Table) Blocks() []int64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	res := make([]int64, 0, len(t.blocks))
+	for id := range t.blocks {
+		res = append(res, id)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+// Block returns a copy of the counts of block id.
+func (t *Table) Block(id int64) *Counts {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if c, ok := t.blocks[id]; ok {
+		return c.Clone()
+	}
+	return New(t.radix)
+}
+
+// Total returns the counts of all blocks.
+func (t *Table) Total() *Counts {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	res := New(t.radix)
+	for _, c := range t.blocks {
+		res.Merge(c)
+	}
+	return res
+}
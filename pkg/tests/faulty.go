@@ -0,0 +1,88 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
+)
+
+// Fault is a failure injected into a range reader by FaultyBucket.
+// The zero value injects no failure.
+type Fault struct {
+	// OpenErr is returned by NewRangeReader if not nil.
+	OpenErr error
+	// Faults are injected as by memory.Client. An Err of io.EOF simulates
+	// a response cut short.
+	memory.Faults
+}
+
+// FaultFunc returns the fault of the call-th NewRangeReader call (from 0)
+// for [off, off+length) of object name.
+type FaultFunc func(call int, name string, off, length int64) Fault
+
+// FaultyBucket wraps a bucket and injects faults into the range readers of
+// its objects.
+type FaultyBucket struct {
+	bucket obj.Bucket
+	fault  FaultFunc
+
+	lock  sync.Mutex
+	calls int
+}
+
+var _ obj.Bucket = new(FaultyBucket)
+
+// NewFaultyBucket returns a bucket injecting the faults returned by fault
+// into the objects of bucket.
+func NewFaultyBucket(bucket obj.Bucket, fault FaultFunc) *FaultyBucket {
+	return &FaultyBucket{bucket: bucket, fault: fault}
+}
+
+// Calls returns the number of NewRangeReader calls so far.
+func (b *FaultyBucket) Calls() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.calls
+}
+
+func (b *FaultyBucket) Object(name string) obj.Object {
+	return &faultyObject{b: b, name: name, o: b.bucket.Object(name)}
+}
+
+type faultyObject struct {
+	b    *FaultyBucket
+	name string
+	o    obj.Object
+}
+
+func (o *faultyObject) NewRangeReader(ctx context.Context, off, length int64) (io.ReadCloser, error) {
+	o.b.lock.Lock()
+	call := o.b.calls
+	o.b.calls++
+	o.b.lock.Unlock()
+
+	f := o.b.fault(call, o.name, off, length)
+	if f.OpenErr != nil {
+		return nil, f.OpenErr
+	}
+	return f.Faults.Open(ctx, func() (io.ReadCloser, error) {
+		return o.o.NewRangeReader(ctx, off, length)
+	})
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/cached"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFault = errors.New("injected fault")
+
+// errTooManyCalls stops readers retrying forever.
+var errTooManyCalls = errors.New("too many NewRangeReader calls")
+
+const maxCalls = 100000
+
+type faultCase struct {
+	name string
+	// ok is set if reads must succeed despite the faults.
+	ok    bool
+	fault tests.FaultFunc
+}
+
+// inBlock reports whether name is the file of block id.
+func inBlock(name string, id int) bool {
+	return strings.HasSuffix(name, fmt.Sprintf(" - %d.ycd", id))
+}
+
+func faultCases(firstDigitOffset int64) []faultCase {
+	return []faultCase{
+		{"None", true, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{}
+		}},
+		{"Single byte reads", true, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{ReadSize: 1}}
+		}},
+		{"Short reads", true, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{ReadSize: 7}}
+		}},
+		{"Responses cut short", true, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{Err: io.EOF, ErrAfter: 5}}
+		}},
+		{"Empty responses", false, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{Err: io.EOF}}
+		}},
+		{"Empty responses in block 3", false, func(_ int, name string, _, _ int64) tests.Fault {
+			if inBlock(name, 3) {
+				return tests.Fault{Faults: memory.Faults{Err: io.EOF}}
+			}
+			return tests.Fault{}
+		}},
+		{"Unexpected EOF", false, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{Err: io.ErrUnexpectedEOF}}
+		}},
+		{"Unexpected EOF at block boundaries", false, func(_ int, _ string, off, _ int64) tests.Fault {
+			if off == firstDigitOffset {
+				return tests.Fault{Faults: memory.Faults{Err: io.ErrUnexpectedEOF}}
+			}
+			return tests.Fault{}
+		}},
+		{"Unexpected EOF mid-word", false, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{Err: io.ErrUnexpectedEOF, ErrAfter: 12}}
+		}},
+		{"Read errors", false, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{Faults: memory.Faults{Err: errFault, ErrAfter: 20}}
+		}},
+		{"Open errors", false, func(int, string, int64, int64) tests.Fault {
+			return tests.Fault{OpenErr: errFault}
+		}},
+		{"First open fails", false, func(call int, _ string, _, _ int64) tests.Fault {
+			if call == 0 {
+				return tests.Fault{OpenErr: errFault}
+			}
+			return tests.Fault{}
+		}},
+		{"Open errors in the last block", false, func(_ int, name string, _, _ int64) tests.Fault {
+			if inBlock(name, 6) {
+				return tests.Fault{OpenErr: errFault}
+			}
+			return tests.Fault{}
+		}},
+	}
+}
+
+// limitCalls makes readers fail instead of retrying forever.
+func limitCalls(fault tests.FaultFunc) tests.FaultFunc {
+	return func(call int, name string, off, length int64) tests.Fault {
+		if call >= maxCalls {
+			return tests.Fault{OpenErr: errTooManyCalls}
+		}
+		return fault(call, name, off, length)
+	}
+}
+
+// checkDigits checks that got[:n] are the first digits of want and that an
+// error other than io.EOF is returned if they are fewer. want is clamped to
+// the end of the result set.
+func checkDigits(t *testing.T, ok bool, want string, got []byte, n int, err error, msg string) {
+	t.Helper()
+	assert.NotErrorIs(t, err, errTooManyCalls, msg)
+	if !assert.LessOrEqual(t, n, len(want), msg) {
+		return
+	}
+	assert.Equal(t, want[:n], string(got[:n]), msg)
+	if n < len(want) {
+		if assert.Error(t, err, msg) {
+			assert.NotEqual(t, io.EOF, err, msg)
+		}
+	}
+	if ok && err != io.EOF {
+		assert.NoError(t, err, msg)
+	}
+}
+
+type newUnpackReader func(bucket obj.Bucket) (*unpack.UnpackReader, func())
+
+func testFaults(t *testing.T, f *tests.Fixture, cases []faultCase, newReader newUnpackReader) {
+	total := len(f.Digits)
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			bucket := tests.NewFaultyBucket(f.Bucket(), limitCalls(tc.fault))
+
+			t.Run("ReadAt", func(t *testing.T) {
+				rd, done := newReader(bucket)
+				defer done()
+				for off := 0; off < total; off += 11 {
+					for _, n := range []int{1, 19, 45, 100} {
+						end := off + n
+						if end > total {
+							end = total
+						}
+						buf := make([]byte, n)
+						read, err := rd.ReadAt(buf, int64(off))
+						checkDigits(t, tc.ok, f.Digits[off:end], buf, read, err,
+							fmt.Sprintf("off %d n %d", off, n))
+					}
+				}
+			})
+
+			for _, size := range []int{1, 20, 64} {
+				size := size
+				t.Run(fmt.Sprintf("Read %d", size), func(t *testing.T) {
+					rd, done := newReader(bucket)
+					defer done()
+					var got []byte
+					var err error
+					buf := make([]byte, size)
+					for err == nil {
+						var n int
+						n, err = rd.Read(buf)
+						got = append(got, buf[:n]...)
+					}
+					if err == io.EOF {
+						// Read may stop anywhere on errors but not at EOF.
+						err = nil
+					}
+					checkDigits(t, tc.ok, f.Digits, got, len(got), err, fmt.Sprintf("size %d", size))
+				})
+			}
+		})
+	}
+}
+
+func TestFaults_Unpack(t *testing.T) {
+	t.Parallel()
+	for _, fc := range []struct {
+		number    string
+		radix     int
+		blockSize int64
+	}{
+		{tests.PiDec, 10, 45},
+		{tests.PiHex, 16, 40},
+	} {
+		f := newFixture(t, fc.number, fc.radix, fc.blockSize)
+		require.Len(t, f.Set, 7)
+		cases := faultCases(int64(f.Set[0].FirstDigitOffset))
+		ctx := context.Background()
+
+		t.Run(fmt.Sprintf("Radix %d", fc.radix), func(t *testing.T) {
+			t.Parallel()
+			testFaults(t, f, cases, func(bucket obj.Bucket) (*unpack.UnpackReader, func()) {
+				rr := f.Set.NewReader(ctx, bucket)
+				return unpack.NewReader(ctx, rr), func() { rr.Close() }
+			})
+		})
+		t.Run(fmt.Sprintf("Radix %d Cached", fc.radix), func(t *testing.T) {
+			t.Parallel()
+			testFaults(t, f, cases, func(bucket obj.Bucket) (*unpack.UnpackReader, func()) {
+				rr := f.Set.NewReader(ctx, bucket)
+				// A small cache covering the first block and a half.
+				cache := cached.NewCache(f.Set, int(f.Set.BlockByteLength()*3/2))
+				return unpack.NewReader(ctx, cached.NewCachedReader(ctx, cache, rr)), func() { rr.Close() }
+			})
+		})
+	}
+}
+
+func TestFaults_TruncatedObject(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f := newFixture(t, tests.PiDec, 10, 45)
+	for _, block := range []int{0, 3, 6} {
+		for _, cut := range []int{1, 8, 12, 24} {
+			client := f.Client()
+			name := f.Set[block].Name
+			data := f.Files[name]
+			client.Put(tests.FixtureBucket, name, data[:len(data)-cut])
+			msg := fmt.Sprintf("block %d cut %d", block, cut)
+
+			rr := f.Set.NewReader(ctx, client.Bucket(tests.FixtureBucket))
+			rd := unpack.NewReader(ctx, rr)
+			got, err := io.ReadAll(rd)
+			checkDigits(t, false, f.Digits, got, len(got), err, msg)
+			assert.Error(t, err, msg)
+
+			buf := make([]byte, len(f.Digits))
+			n, err := rd.ReadAt(buf, 0)
+			checkDigits(t, false, f.Digits, buf, n, err, msg)
+			assert.Error(t, err, msg)
+			assert.NotEqual(t, io.EOF, err, msg)
+			rr.Close()
+		}
+	}
+}
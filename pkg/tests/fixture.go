@@ -0,0 +1,134 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj"
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
+	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+)
+
+// FixtureBucket is the name of the bucket storing the files of a Fixture.
+const FixtureBucket = "fixture"
+
+// firstDigitsLen is the number of digits after the decimal point
+// y-cruncher writes in the FirstDigits field.
+const firstDigitsLen = 50
+
+// Fixture is a result set packed from a digit string with its ycd files
+// stored in memory.
+type Fixture struct {
+	// Set is the result set sorted by block ID.
+	Set resultset.ResultSet
+	// Digits are the digits after the decimal point, "14159..." for pi.
+	Digits string
+	// Files maps the names of the files in Set to their contents.
+	Files map[string][]byte
+}
+
+// NewFixture packs number, e.g. "3.14159...", in radix into blocks of
+// blockSize digits after the decimal point. The last block is shorter if the
+// number of digits isn't a multiple of blockSize, in which case TotalDigits
+// is set in every file.
+func NewFixture(number string, radix int, blockSize int64) (*Fixture, error) {
+	return NewConstantFixture("Fixture", number, radix, blockSize)
+}
+
+// NewConstantFixture is like NewFixture with files named after constant as
+// y-cruncher does, e.g. "Sqrt(2) - Dec/Sqrt(2) - Dec - 0.ycd".
+func NewConstantFixture(constant, number string, radix int, blockSize int64) (*Fixture, error) {
+	dot := strings.IndexByte(number, '.')
+	if dot <= 0 || dot == len(number)-1 {
+		return nil, fmt.Errorf("NewFixture: no digits before or after the decimal point: %q", number)
+	}
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("NewFixture: invalid block size: %d", blockSize)
+	}
+	digits := number[dot+1:]
+	first := number
+	if len(digits) > firstDigitsLen {
+		first = number[:dot+1+firstDigitsLen]
+	}
+	total := int64(0)
+	if int64(len(digits))%blockSize != 0 {
+		total = int64(len(digits))
+	}
+	prefix := constant + " - Dec"
+	if radix == 16 {
+		prefix = constant + " - Hex"
+	}
+
+	f := &Fixture{
+		Digits: digits,
+		Files:  make(map[string][]byte),
+	}
+	for id := int64(0); id*blockSize < int64(len(digits)); id++ {
+		h := &ycd.Header{
+			FileVersion: "1.1.0",
+			Radix:       radix,
+			FirstDigits: first,
+			TotalDigits: total,
+			BlockSize:   blockSize,
+			BlockID:     id,
+		}
+		var buf bytes.Buffer
+		w, err := ycd.NewWriter(&buf, h)
+		if err != nil {
+			return nil, err
+		}
+		end := (id + 1) * blockSize
+		if end > int64(len(digits)) {
+			end = int64(len(digits))
+		}
+		if _, err := w.WriteString(digits[id*blockSize : end]); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		name := fmt.Sprintf("%s/%s - %d.ycd", prefix, prefix, id)
+		f.Set = append(f.Set, w.File(name))
+		f.Files[name] = buf.Bytes()
+	}
+	return f, nil
+}
+
+// Client returns a memory.Client serving the files of f in FixtureBucket.
+func (f *Fixture) Client() *memory.Client {
+	return NewClient(f)
+}
+
+// Bucket returns FixtureBucket.
+func (f *Fixture) Bucket() obj.Bucket {
+	return f.Client().Bucket(FixtureBucket)
+}
+
+// NewClient returns a memory.Client serving the files of fixtures in FixtureBucket.
+// Fixtures of different constants or radixes can share a client as their file
+// names differ.
+func NewClient(fixtures ...*Fixture) *memory.Client {
+	c := memory.NewClient()
+	for _, f := range fixtures {
+		for name, data := range f.Files {
+			c.Put(FixtureBucket, name, data)
+		}
+	}
+	return c
+}
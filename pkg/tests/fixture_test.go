@@ -0,0 +1,205 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The fixtures are tested through the packages depending on tests.
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/service"
+	"github.com/googlecloudplatform/pi-delivery/pkg/tests"
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var fixtureCases = []struct {
+	number    string
+	radix     int
+	blockSize int64
+	blocks    int
+	total     int64
+}{
+	// Word aligned blocks.
+	{tests.PiDec, 10, 100, 3, 0},
+	{tests.PiHex, 16, 50, 5, 0},
+	// Blocks ending in the middle of a word and a short last block.
+	{tests.PiDec, 10, 45, 7, 300},
+	{tests.PiHex, 16, 40, 7, 250},
+	// A single word per block.
+	{tests.PiDec, 10, 19, 16, 300},
+	// Everything in a partial word.
+	{"3.14", 10, 45, 1, 2},
+}
+
+func newFixture(t *testing.T, number string, radix int, blockSize int64) *tests.Fixture {
+	t.Helper()
+	f, err := tests.NewFixture(number, radix, blockSize)
+	require.NoError(t, err)
+	return f
+}
+
+func TestFixture_Parse(t *testing.T) {
+	t.Parallel()
+	for _, tc := range fixtureCases {
+		f := newFixture(t, tc.number, tc.radix, tc.blockSize)
+		require.Len(t, f.Set, tc.blocks)
+		assert.Equal(t, tc.number[2:], f.Digits)
+		assert.Equal(t, tc.radix, f.Set.Radix())
+		assert.Equal(t, tc.number[0], f.Set.FirstDigit())
+		assert.Equal(t, int64(len(f.Digits)), f.Set.TotalDigits())
+		for i, file := range f.Set {
+			assert.Equal(t, int64(i), file.Header.BlockID)
+			assert.Equal(t, tc.total, file.Header.TotalDigits)
+			parsed, err := ycd.Parse(bytes.NewReader(f.Files[file.Name]))
+			require.NoError(t, err)
+			parsed.Name = file.Name
+			assert.Equal(t, file, parsed)
+		}
+	}
+
+	_, err := tests.NewFixture("31415", 10, 19)
+	assert.Error(t, err)
+	_, err = tests.NewFixture("3.", 10, 19)
+	assert.Error(t, err)
+	_, err = tests.NewFixture("3.14", 10, 0)
+	assert.Error(t, err)
+	_, err = tests.NewFixture("3.14", 37, 19)
+	assert.ErrorIs(t, err, ycd.ErrInvalidHeader)
+}
+
+func TestFixture_Client(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f := newFixture(t, tests.PiDec, 10, 45)
+	name := f.Set[0].Name
+	data := f.Files[name]
+	object := f.Bucket().Object(name)
+
+	rd, err := object.NewRangeReader(ctx, 10, 20)
+	require.NoError(t, err)
+	b, err := io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, data[10:30], b)
+	assert.NoError(t, rd.Close())
+
+	rd, err = object.NewRangeReader(ctx, int64(len(data))-5, 20)
+	require.NoError(t, err)
+	b, err = io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, data[len(data)-5:], b)
+
+	rd, err = object.NewRangeReader(ctx, 0, -1)
+	require.NoError(t, err)
+	b, err = io.ReadAll(rd)
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	_, err = object.NewRangeReader(ctx, int64(len(data)), 1)
+	assert.ErrorIs(t, err, io.EOF)
+	_, err = object.NewRangeReader(ctx, -1, 1)
+	assert.Error(t, err)
+	_, err = f.Bucket().Object("missing.ycd").NewRangeReader(ctx, 0, 1)
+	assert.Error(t, err)
+	_, err = f.Client().Bucket("other").Object(name).NewRangeReader(ctx, 0, 1)
+	assert.Error(t, err)
+	assert.NoError(t, f.Client().Close())
+}
+
+func TestFixture_Unpack(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, tc := range fixtureCases {
+		f := newFixture(t, tc.number, tc.radix, tc.blockSize)
+		t.Run(fmt.Sprintf("Radix %d Block %d", tc.radix, tc.blockSize), func(t *testing.T) {
+			rr := f.Set.NewReader(ctx, f.Bucket())
+			defer rr.Close()
+			rd := unpack.NewReader(ctx, rr)
+
+			all, err := io.ReadAll(rd)
+			assert.NoError(t, err)
+			assert.Equal(t, f.Digits, string(all))
+
+			total := len(f.Digits)
+			for off := 0; off < total; off += 7 {
+				for _, n := range []int{1, 19, 50, 101} {
+					buf := make([]byte, n)
+					read, err := rd.ReadAt(buf, int64(off))
+					end := off + n
+					if end > total {
+						end = total
+						assert.ErrorIs(t, err, io.EOF, "off %d n %d", off, n)
+					} else {
+						assert.NoError(t, err, "off %d n %d", off, n)
+					}
+					assert.Equal(t, f.Digits[off:end], string(buf[:read]), "off %d n %d", off, n)
+				}
+			}
+			_, err = rd.ReadAt(make([]byte, 1), int64(total))
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestFixture_Service(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dec := newFixture(t, tests.PiDec, 10, 45)
+	hex := newFixture(t, tests.PiHex, 16, 40)
+	logger := zap.NewNop().Sugar()
+	s := service.NewServiceWithClient(logger, tests.NewClient(dec, hex), tests.FixtureBucket)
+	defer s.Close()
+	require.NoError(t, s.Register("pi-dec", dec.Set, tests.FixtureBucket))
+	require.NoError(t, s.Register("pi-hex", hex.Set, tests.FixtureBucket))
+
+	for _, tc := range []struct {
+		name     string
+		number   string
+		start, n int64
+	}{
+		{"pi-dec", tests.PiDec, 0, 1},
+		{"pi-dec", tests.PiDec, 0, 100},
+		{"pi-dec", tests.PiDec, 1, 100},
+		{"pi-dec", tests.PiDec, 44, 3},
+		{"pi-dec", tests.PiDec, 250, 100},
+		{"pi-dec", tests.PiDec, 300, 1},
+		{"pi-hex", tests.PiHex, 0, 60},
+		{"pi-hex", tests.PiHex, 39, 3},
+		{"pi-hex", tests.PiHex, 200, 100},
+	} {
+		// Position 0 is the digit before the decimal point.
+		digits := tc.number[:1] + tc.number[2:]
+		end := tc.start + tc.n
+		if end > int64(len(digits)) {
+			end = int64(len(digits))
+		}
+		res, err := s.GetByName(ctx, tc.name, tc.start, tc.n)
+		if assert.NoError(t, err, "%s %d %d", tc.name, tc.start, tc.n) {
+			assert.Equal(t, digits[tc.start:end], string(res), "%s %d %d", tc.name, tc.start, tc.n)
+		}
+	}
+
+	res, err := s.Get(ctx, logger, dec.Set, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "3141592653", string(res))
+	_, err = s.GetByName(ctx, "pi-dec", 302, 1)
+	assert.ErrorIs(t, err, service.ErrOutOfRange)
+}
@@ -0,0 +1,27 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+// The first 300 decimal and 250 hexadecimal digits of pi after the decimal point.
+const (
+	PiDec = "3." +
+		"1415926535897932384626433832795028841971693993751058209749445923078164062862089986280348253421170679" +
+		"8214808651328230664709384460955058223172535940812848111745028410270193852110555964462294895493038196" +
+		"4428810975665933446128475648233786783165271201909145648566923460348610454326648213393607260249141273"
+	PiHex = "3." +
+		"243f6a8885a308d313198a2e03707344a4093822299f31d0082efa98ec4e6c89452821e638d01377be5466cf34e90c6cc0ac" +
+		"29b7c97c50dd3f84d5b5b54709179216d5d98979fb1bd1310ba698dfb5ac2ffd72dbd01adfb7b8e1afed6a267e96ba7c9045" +
+		"f12c7f9924a19947b3916cf70801f2e2858efc16636920d871"
+)
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"io"
 
+	"github.com/googlecloudplatform/pi-delivery/pkg/obj/memory"
 	"github.com/googlecloudplatform/pi-delivery/pkg/resultset"
 )
 
@@ -57,3 +58,21 @@ func NewTestReader(set resultset.ResultSet, idx int, buf []byte, off, length int
 			buf[off+base : end+base]),
 	), nil
 }
+
+// PutResultSet stores the files of set in bucket of c with buf as the packed
+// digits of the result set, i.e. file i holds the i-th set.BlockByteLength()
+// bytes of buf, or fewer at the end of buf. Headers are filled with zeros.
+func PutResultSet(c *memory.Client, bucket string, set resultset.ResultSet, buf []byte) {
+	for i, f := range set {
+		start := int64(i) * set.BlockByteLength()
+		end := start + set.BlockByteLength()
+		if end > int64(len(buf)) {
+			end = int64(len(buf))
+		}
+		data := make([]byte, f.FirstDigitOffset)
+		if start < end {
+			data = append(data, buf[start:end]...)
+		}
+		c.Put(bucket, f.Name, data)
+	}
+}
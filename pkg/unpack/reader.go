@@ -33,7 +33,8 @@ type UpstreamReader interface {
 }
 
 // UnpackReader reads from the Upstream Reader and converts packed digits
-// to unpacked string representation ("14159...").
+// to unpacked string representation ("14159..."), or to digit values
+// ({1, 4, 1, 5, 9, ...}) with DigitValues.
 // Note the first offset is still the first digit after the decimal point as in
 // the packed format.
 type UnpackReader struct {
@@ -44,6 +45,7 @@ type UnpackReader struct {
 	rd          UpstreamReader
 	seeked      bool
 	unread      []byte
+	values      bool
 }
 
 var _ io.ReadSeeker = new(UnpackReader)
@@ -51,14 +53,30 @@ var _ io.ReaderAt = new(UnpackReader)
 
 var ErrNotFullWord = errors.New("read bytes are not full words")
 
+// Option configures an UnpackReader.
+type Option func(*UnpackReader)
+
+// DigitValues makes the reader return the values of the digits, 0 to radix-1,
+// instead of their ASCII characters, for callers doing arithmetic on them.
+// ToASCII converts the values back.
+func DigitValues() Option {
+	return func(r *UnpackReader) {
+		r.values = true
+	}
+}
+
 // NewReader returns a new UnpackReader for UpstreamReader rd
-func NewReader(ctx context.Context, rd UpstreamReader) *UnpackReader {
-	return &UnpackReader{
+func NewReader(ctx context.Context, rd UpstreamReader, opts ...Option) *UnpackReader {
+	r := &UnpackReader{
 		radix:       rd.ResultSet().Radix(),
 		totalDigits: rd.ResultSet().TotalDigits(),
 		blockSize:   rd.ResultSet().BlockSize(),
 		rd:          rd,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // ReadAt reads len(p) bytes of unpacked digits starting at the off-th digit.
@@ -79,18 +97,25 @@ func (r *UnpackReader) ReadAt(p []byte, off int64) (int, error) {
 	if read == 0 {
 		return 0, err
 	}
-	if read%WordSize != 0 {
+	if err == nil && read%WordSize != 0 {
 		return 0, fmt.Errorf("read %v bytes: %w", read, ErrNotFullWord)
 	}
 	remaining := len(p)
 	if remaining > int(r.totalDigits-off) {
 		remaining = int(r.totalDigits - off)
-		err = io.EOF
+		if err == nil {
+			err = io.EOF
+		}
 	}
-	written, perr := r.unpack(p[:remaining], packed[:read], off, pre)
+	// Only the full words read are unpacked if the read failed.
+	written, perr := r.unpack(p[:remaining], packed[:read/WordSize*WordSize], off, pre)
 	if perr != nil {
 		return written, fmt.Errorf("unpack error at off %v: %w", off, perr)
 	}
+	if written < remaining && (err == nil || err == io.EOF) {
+		// The result set ended before its total digits.
+		err = io.ErrUnexpectedEOF
+	}
 	return written, err
 }
 
@@ -124,26 +149,41 @@ func (r *UnpackReader) Read(p []byte) (int, error) {
 		}
 	}
 
-	n, err := io.ReadFull(r.rd, packed[read:])
-	read += n
+	// Unlike io.ReadFull, this keeps io.ErrUnexpectedEOF from upstream
+	// (truncated data) apart from io.EOF (the end of the result set).
+	var err error
+	for read < len(packed) && err == nil {
+		var n int
+		n, err = r.rd.Read(packed[read:])
+		read += n
+	}
 
 	remaining := len(p)
 	if remaining > int(r.totalDigits-r.off) {
 		remaining = int(r.totalDigits - r.off)
 	}
-	n, perr := r.unpack(p[:remaining], packed[:read], r.off, pre)
+	// Only the full words read are unpacked if the read failed.
+	n, perr := r.unpack(p[:remaining], packed[:read/WordSize*WordSize], r.off, pre)
 	r.off += int64(n)
 	written += n
 
-	if read%WordSize != 0 {
-		return written, fmt.Errorf("off %v, read bytes %v: %w", r.off, n, ErrNotFullWord)
-	}
-	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+	if err != nil && err != io.EOF {
+		// The upstream offset no longer matches r.off.
+		r.seeked = true
 		return written, fmt.Errorf("read error at off %v: %w", r.off, err)
 	}
+	if read%WordSize != 0 {
+		r.seeked = true
+		return written, fmt.Errorf("off %v, read bytes %v: %w", r.off, read, ErrNotFullWord)
+	}
 	if perr != nil {
+		r.seeked = true
 		poff, _ := r.rd.Seek(0, io.SeekCurrent)
-		return written, fmt.Errorf("unpack error at off %v, packed off %v: %w", r.off, poff, err)
+		return written, fmt.Errorf("unpack error at off %v, packed off %v: %w", r.off, poff, perr)
+	}
+	if err == io.EOF && written < remaining {
+		r.seeked = true
+		return written, fmt.Errorf("read error at off %v: %w", r.off, io.ErrUnexpectedEOF)
 	}
 
 	if int64(read) == packedN && post > 0 {
@@ -151,9 +191,6 @@ func (r *UnpackReader) Read(p []byte) (int, error) {
 		copy(r.unread, packed[read-WordSize:])
 	}
 
-	if err == io.ErrUnexpectedEOF {
-		return written, io.EOF
-	}
 	return written, err
 }
 
@@ -180,6 +217,14 @@ func (r *UnpackReader) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (r *UnpackReader) unpack(unpacked, packed []byte, offset int64, pre int) (int, error) {
+	written, err := r.unpackASCII(unpacked, packed, offset, pre)
+	if r.values {
+		FromASCII(unpacked[:written])
+	}
+	return written, err
+}
+
+func (r *UnpackReader) unpackASCII(unpacked, packed []byte, offset int64, pre int) (int, error) {
 	poff := 0
 	written := 0
 	dpw := ycd.DigitsPerWord(r.radix)
@@ -192,6 +237,16 @@ func (r *UnpackReader) unpack(unpacked, packed []byte, offset int64, pre int) (i
 
 		}
 		reqBytes := (reqDigits + dpw - 1) / dpw * WordSize
+		if avail := len(packed) - poff; reqBytes > avail {
+			// Short of words; unpack the digits of the words there are.
+			reqBytes = avail
+			if d := reqBytes/WordSize*dpw - pre; d < reqDigits {
+				reqDigits = d
+			}
+			if reqDigits <= 0 {
+				break
+			}
+		}
 		n, err := UnpackBlock(unpacked[written:written+reqDigits], packed[poff:poff+reqBytes], r.radix, pre)
 		poff += reqBytes
 		written += n
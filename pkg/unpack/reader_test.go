@@ -82,7 +82,7 @@ func TestUnpack_UnpackReader(t *testing.T) {
 			require.NotNil(t, rr)
 			defer assert.NoError(t, rr.Close())
 
-			reader := NewReader(ctx, cached.NewCachedReader(ctx, rr))
+			reader := NewReader(ctx, cached.NewCachedReader(ctx, cached.NewCache(testSet, cached.DefaultCacheSize), rr))
 			require.NotNil(t, reader)
 
 			buf := make([]byte, len(tc.expected))
@@ -432,3 +432,44 @@ var testDecMultipleBlocks = []byte{
 	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // dummy data (reader should ignore this).
 	// Block Boundary
 }
+
+func TestUnpack_DigitValues(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, fc := range []struct {
+		number    string
+		radix     int
+		blockSize int64
+	}{
+		{tests.PiDec, 10, 45},
+		{tests.PiHex, 16, 40},
+	} {
+		f, err := tests.NewFixture(fc.number, fc.radix, fc.blockSize)
+		require.NoError(t, err)
+		want := []byte(f.Digits)
+		FromASCII(want)
+		for _, v := range want {
+			require.Less(t, int(v), fc.radix)
+		}
+
+		rr := f.Set.NewReader(ctx, f.Bucket())
+		rd := NewReader(ctx, rr, DigitValues())
+		got, err := io.ReadAll(rd)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, "radix %d", fc.radix)
+		ToASCII(got)
+		assert.Equal(t, f.Digits, string(got), "radix %d", fc.radix)
+
+		for off := 0; off < len(want); off += 13 {
+			buf := make([]byte, 50)
+			n, err := rd.ReadAt(buf, int64(off))
+			if off+len(buf) > len(want) {
+				assert.ErrorIs(t, err, io.EOF)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, want[off:off+n], buf[:n], "radix %d off %d", fc.radix, off)
+		}
+		rr.Close()
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unpack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+)
+
+// The strconv based implementation UnpackBlock replaced, kept to check that
+// both produce the same results.
+
+// zeros holds the leading zeros of a word in radix 10, the longest.
+const zeros = "0000000000000000000"
+
+func copyWithZero(dst []byte, s string, nz int) int {
+	return copy(dst, zeros[:nz]) + copy(dst[nz:], s)
+}
+
+// unpackBlockStrconv is the previous implementation of UnpackBlock.
+func unpackBlockStrconv(unpacked, packed []byte, radix, pre int) (int, error) {
+	if len(packed) == 0 || len(unpacked) == 0 {
+		return 0, nil
+	}
+
+	dpw := ycd.DigitsPerWord(radix)
+	if dpw == 0 {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownRadix, radix)
+	}
+
+	unpackedLen := UnpackedLen(int64(len(packed)-1), radix) - int64(pre)
+	if int64(len(unpacked)) < unpackedLen {
+		return 0, fmt.Errorf("%w: required = %v bytes, actual buffer = %v bytes",
+			ErrBufferTooSmall, unpackedLen, len(unpacked))
+	}
+
+	// Unpack the first word with pre.
+	// Copy dpw-pre bytes.
+	s := strconv.FormatUint(binary.LittleEndian.Uint64(packed), radix)
+	nz := dpw - len(s)
+	if nz < 0 {
+		return 0, fmt.Errorf("%w: word = %16x, unpacked = %s",
+			ErrInvalidWord, packed[:WordSize], s)
+	}
+	nzNeeded := nz - pre
+	if nzNeeded < 0 {
+		nzNeeded = 0
+	}
+	n := copy(unpacked, zeros[:nzNeeded])
+	if n < dpw-pre && n < len(unpacked) {
+		if nz < pre {
+			n += copy(unpacked[n:], s[pre-nz:dpw-pre-n+(pre-nz)])
+		} else {
+			n += copy(unpacked[n:], s[:dpw-pre-n])
+		}
+	}
+
+	if len(packed) == WordSize {
+		return n, nil
+	}
+
+	// Process until the second last word.
+	for i := WordSize; i < len(packed)-WordSize; i += WordSize {
+		s := strconv.FormatUint(binary.LittleEndian.Uint64(packed[i:]), radix)
+		nz := dpw - len(s)
+		if nz < 0 {
+			return n, fmt.Errorf("%w: word = %16x, unpacked = %s", ErrInvalidWord,
+				packed[i:i+WordSize], s)
+		}
+		n += copyWithZero(unpacked[n:], s, nz)
+	}
+
+	// Process the last word with post.
+	s = strconv.FormatUint(binary.LittleEndian.Uint64(packed[len(packed)-WordSize:]), radix)
+	nz = dpw - len(s)
+	if nz < 0 {
+		return n, fmt.Errorf("%w: word = %16x, unpacked = %s", ErrInvalidWord,
+			packed[len(packed)-WordSize:], s)
+	}
+	n += copy(unpacked[n:], zeros[:nz])
+	if n < len(unpacked) {
+		n += copy(unpacked[n:], s)
+	}
+	return n, nil
+}
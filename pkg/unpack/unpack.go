@@ -27,13 +27,105 @@ var ErrUnknownRadix error = errors.New("Unpack: unknown radix")
 var ErrBufferTooSmall error = errors.New("Unpack: destination buffer is too small")
 var ErrInvalidWord error = errors.New("Unpack: invalid word")
 
-const (
-	zeros    = "0000000000000000000"
-	WordSize = ycd.WordSize
-)
+// WordSize is the size of a packed word.
+const WordSize = ycd.WordSize
+
+// digits are the digits of every radix, as written by strconv.
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// pairs holds the 2-digit decimal strings "00" to "99".
+var pairs = func() (t [200]byte) {
+	for i := 0; i < 100; i++ {
+		t[2*i] = byte('0' + i/10)
+		t[2*i+1] = byte('0' + i%10)
+	}
+	return t
+}()
+
+// hexPairs holds the 2-digit hexadecimal strings "00" to "ff".
+var hexPairs = func() (t [512]byte) {
+	for i := 0; i < 256; i++ {
+		t[2*i] = digits[i>>4]
+		t[2*i+1] = digits[i&0xf]
+	}
+	return t
+}()
+
+// values maps the digits to their values and everything else to 0xff.
+var values = func() (t [256]byte) {
+	for i := range t {
+		t[i] = 0xff
+	}
+	for i := 0; i < len(digits); i++ {
+		t[digits[i]] = byte(i)
+	}
+	return t
+}()
+
+// wordLimits[radix] is radix^DigitsPerWord(radix), the smallest invalid
+// word, or 0 if every word is valid as it is 2^64.
+var wordLimits = func() (t [ycd.MaxRadix + 1]uint64) {
+	for _, radix := range ycd.Radices {
+		t[radix] = 1
+		for i := 0; i < ycd.DigitsPerWord(radix); i++ {
+			t[radix] *= uint64(radix)
+		}
+	}
+	return t
+}()
+
+// ValidWord reports whether w is a valid packed word in radix, i.e. it has
+// at most DigitsPerWord(radix) digits. radix must be supported by ycd.
+func ValidWord(w uint64, radix int) bool {
+	limit := wordLimits[radix]
+	return limit == 0 || w < limit
+}
+
+// format4 writes the 4 decimal digits of x < 10000 to dst.
+func format4(dst []byte, x uint32) {
+	q, r := x/100, x%100
+	dst[0], dst[1] = pairs[2*q], pairs[2*q+1]
+	dst[2], dst[3] = pairs[2*r], pairs[2*r+1]
+}
+
+// format8 writes the 8 decimal digits of x < 10^8 to dst.
+func format8(dst []byte, x uint32) {
+	format4(dst[:4], x/10000)
+	format4(dst[4:8], x%10000)
+}
+
+// formatWord writes the DigitsPerWord(radix) digits of the valid word w to dst
+// with leading zeros, without the allocations of strconv.
+func formatWord(dst []byte, w uint64, radix int) {
+	switch radix {
+	case 10:
+		// 3 + 8 + 8 digits, in 32 bit arithmetic past the first division.
+		hi := w / 1e16
+		lo := w - hi*1e16
+		mid := uint32(lo / 1e8)
+		dst[0] = byte('0' + hi/100)
+		dst[1], dst[2] = pairs[2*(hi%100)], pairs[2*(hi%100)+1]
+		format8(dst[3:11], mid)
+		format8(dst[11:19], uint32(lo-uint64(mid)*1e8))
+	case 16:
+		_ = dst[15]
+		for i := 14; i >= 0; i -= 2 {
+			b := w & 0xff
+			dst[i], dst[i+1] = hexPairs[2*b], hexPairs[2*b+1]
+			w >>= 8
+		}
+	default:
+		r := uint64(radix)
+		for i := ycd.DigitsPerWord(radix) - 1; i >= 0; i-- {
+			dst[i] = digits[w%r]
+			w /= r
+		}
+	}
+}
 
-func copyWithZero(dst []byte, s string, nz int) int {
-	return copy(dst, zeros[:nz]) + copy(dst[nz:], s)
+func invalidWord(word []byte, radix int) error {
+	return fmt.Errorf("%w: word = %16x, unpacked = %s", ErrInvalidWord,
+		word[:WordSize], strconv.FormatUint(binary.LittleEndian.Uint64(word), radix))
 }
 
 // UnpackBlock reads packed digits from packed and writes unpacked strings to unpacked.
@@ -43,6 +135,9 @@ func UnpackBlock(unpacked, packed []byte, radix, pre int) (int, error) {
 	}
 
 	dpw := ycd.DigitsPerWord(radix)
+	if dpw == 0 {
+		return 0, fmt.Errorf("%w: %v", ErrUnknownRadix, radix)
+	}
 
 	unpackedLen := UnpackedLen(int64(len(packed)-1), radix) - int64(pre)
 	if int64(len(unpacked)) < unpackedLen {
@@ -50,26 +145,16 @@ func UnpackBlock(unpacked, packed []byte, radix, pre int) (int, error) {
 			ErrBufferTooSmall, unpackedLen, len(unpacked))
 	}
 
-	// Unpack the first word with pre.
-	// Copy dpw-pre bytes.
-	s := strconv.FormatUint(binary.LittleEndian.Uint64(packed), radix)
-	nz := dpw - len(s)
-	if nz < 0 {
-		return 0, fmt.Errorf("%w: word = %16x, unpacked = %s",
-			ErrInvalidWord, packed[:WordSize], s)
-	}
-	nzNeeded := nz - pre
-	if nzNeeded < 0 {
-		nzNeeded = 0
-	}
-	n := copy(unpacked, zeros[:nzNeeded])
-	if n < dpw-pre && n < len(unpacked) {
-		if nz < pre {
-			n += copy(unpacked[n:], s[pre-nz:dpw-pre-n+(pre-nz)])
-		} else {
-			n += copy(unpacked[n:], s[:dpw-pre-n])
-		}
+	// word holds the digits of words not entirely copied to unpacked.
+	var word [64]byte
+
+	// Unpack the first word without the pre digits.
+	w := binary.LittleEndian.Uint64(packed)
+	if !ValidWord(w, radix) {
+		return 0, invalidWord(packed, radix)
 	}
+	formatWord(word[:dpw], w, radix)
+	n := copy(unpacked, word[pre:dpw])
 
 	if len(packed) == WordSize {
 		return n, nil
@@ -77,29 +162,56 @@ func UnpackBlock(unpacked, packed []byte, radix, pre int) (int, error) {
 
 	// Process until the second last word.
 	for i := WordSize; i < len(packed)-WordSize; i += WordSize {
-		s := strconv.FormatUint(binary.LittleEndian.Uint64(packed[i:]), radix)
-		nz := dpw - len(s)
-		if nz < 0 {
-			return n, fmt.Errorf("%w: word = %16x, unpacked = %s", ErrInvalidWord,
-				packed[i:i+WordSize], s)
+		w := binary.LittleEndian.Uint64(packed[i:])
+		if !ValidWord(w, radix) {
+			return n, invalidWord(packed[i:], radix)
+		}
+		if len(unpacked)-n >= dpw {
+			formatWord(unpacked[n:n+dpw], w, radix)
+			n += dpw
+		} else {
+			formatWord(word[:dpw], w, radix)
+			n += copy(unpacked[n:], word[:dpw])
 		}
-		n += copyWithZero(unpacked[n:], s, nz)
 	}
 
-	// Process the last word with post.
-	s = strconv.FormatUint(binary.LittleEndian.Uint64(packed[len(packed)-WordSize:]), radix)
-	nz = dpw - len(s)
-	if nz < 0 {
-		return n, fmt.Errorf("%w: word = %16x, unpacked = %s", ErrInvalidWord,
-			packed[len(packed)-WordSize:], s)
-	}
-	n += copy(unpacked[n:], zeros[:nz])
-	if n < len(unpacked) {
-		n += copy(unpacked[n:], s)
+	// Process the last word, which may be cut short by the end of unpacked.
+	last := packed[len(packed)-WordSize:]
+	w = binary.LittleEndian.Uint64(last)
+	if !ValidWord(w, radix) {
+		return n, invalidWord(last, radix)
 	}
+	formatWord(word[:dpw], w, radix)
+	n += copy(unpacked[n:], word[:dpw])
 	return n, nil
 }
 
+// FromASCII replaces the digits in p, as unpacked by UnpackBlock, with their
+// values, e.g. "3f" with {3, 15}. Other bytes are replaced with 0xff.
+func FromASCII(p []byte) {
+	for i, c := range p {
+		p[i] = values[c]
+	}
+}
+
+// DigitValue returns the value of the lowercase ASCII digit c, e.g. 15 for
+// 'f', or -1 if c is not a digit in radix.
+func DigitValue(c byte, radix int) int {
+	if v := int(values[c]); v < radix {
+		return v
+	}
+	return -1
+}
+
+// ToASCII replaces the digit values in p, as read with DigitValues, with
+// their ASCII characters for output, e.g. {3, 15} with "3f".
+// It panics if a value is not a digit of any supported radix.
+func ToASCII(p []byte) {
+	for i, v := range p {
+		p[i] = digits[v]
+	}
+}
+
 // UnpackedLen returns a number of bytes to store
 // an unpacked sequence for n bytes of packed bytes.
 func UnpackedLen(n int64, radix int) int64 {
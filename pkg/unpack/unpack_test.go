@@ -15,7 +15,12 @@
 package unpack
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
@@ -78,6 +83,9 @@ func TestUnpack_Errors(t *testing.T) {
 		{0, 2 * WordSize, 16, 0, nil},
 		{14, 2 * WordSize, 16, 1, ErrBufferTooSmall},
 		{15, 2 * WordSize, 16, 0, ErrBufferTooSmall},
+		{19, WordSize, 1, 0, ErrUnknownRadix},
+		{19, WordSize, 8, 0, ErrUnknownRadix},
+		{19, WordSize, 37, 0, ErrUnknownRadix},
 	}
 	for _, tc := range testCases {
 		tc := tc
@@ -162,3 +170,124 @@ func TestUnpack_Unpack(t *testing.T) {
 		})
 	}
 }
+
+func TestUnpack_FormatWord(t *testing.T) {
+	t.Parallel()
+	rnd := rand.New(rand.NewSource(1))
+	for _, radix := range ycd.Radices {
+		dpw := ycd.DigitsPerWord(radix)
+		limit := wordLimits[radix]
+		words := []uint64{0, 1, uint64(radix), limit - 1, limit, limit + 1, ^uint64(0)}
+		for i := 0; i < 1000; i++ {
+			words = append(words, rnd.Uint64(), rnd.Uint64()>>uint(rnd.Intn(64)))
+		}
+		for _, w := range words {
+			s := strconv.FormatUint(w, radix)
+			valid := len(s) <= dpw
+			assert.Equal(t, valid, ValidWord(w, radix), "radix %d word %d", radix, w)
+			if !valid {
+				continue
+			}
+			dst := make([]byte, dpw)
+			formatWord(dst, w, radix)
+			assert.Equal(t, strings.Repeat("0", dpw-len(s))+s, string(dst), "radix %d word %d", radix, w)
+		}
+	}
+}
+
+func TestUnpack_ASCII(t *testing.T) {
+	t.Parallel()
+	p := []byte("0123456789abcdefz")
+	FromASCII(p)
+	assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 35}, p)
+	ToASCII(p)
+	assert.Equal(t, "0123456789abcdefz", string(p))
+
+	p = []byte("3.A")
+	FromASCII(p)
+	assert.Equal(t, []byte{3, 0xff, 0xff}, p)
+	assert.Panics(t, func() { ToASCII([]byte{36}) })
+}
+
+func TestUnpack_DigitValue(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 0, DigitValue('0', 10))
+	assert.Equal(t, 9, DigitValue('9', 10))
+	assert.Equal(t, -1, DigitValue('a', 10))
+	assert.Equal(t, 15, DigitValue('f', 16))
+	assert.Equal(t, -1, DigitValue('g', 16))
+	assert.Equal(t, 35, DigitValue('z', 36))
+	assert.Equal(t, -1, DigitValue('2', 2))
+	for _, c := range []byte("A.- \x00\xff") {
+		assert.Equal(t, -1, DigitValue(c, 36), "%q", c)
+	}
+}
+
+func FuzzUnpackBlock(f *testing.F) {
+	f.Add([]byte{0x60, 0xe2, 0x3e, 0xb8, 0xae, 0x61, 0xa6, 0x13}, uint8(10), uint8(0), uint16(19))
+	f.Add([]byte{
+		0x8e, 0x22, 0xa2, 0x31, 0xfe, 0xa8, 0x16, 0x83,
+		0x43, 0xe1, 0x29, 0xbc, 0x73, 0xf4, 0x7c, 0x0c,
+		0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}, uint8(10), uint8(1), uint16(50))
+	f.Add([]byte{
+		0x7a, 0x13, 0x6c, 0x0b, 0xef, 0x6e, 0x98, 0x2a,
+		0xfb, 0x7e, 0x50, 0xf0, 0x3b, 0xba, 0x76, 0x01,
+		0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	}, uint8(16), uint8(15), uint16(33))
+	f.Add(bytes.Repeat([]byte{0xff}, 24), uint8(10), uint8(3), uint16(100))
+	f.Add(bytes.Repeat([]byte{0x12}, 20), uint8(7), uint8(2), uint16(60))
+	f.Fuzz(func(t *testing.T, packed []byte, r, p uint8, size uint16) {
+		if len(packed) < WordSize {
+			return
+		}
+		radix := ycd.Radices[int(r)%len(ycd.Radices)]
+		dpw := ycd.DigitsPerWord(radix)
+		pre := int(p) % dpw
+		max := int(UnpackedLen(int64(len(packed)), radix)) + dpw
+		want := bytes.Repeat([]byte{'x'}, int(size)%(max+1))
+		got := make([]byte, len(want))
+		copy(got, want)
+
+		wantN, wantErr := unpackBlockStrconv(want, packed, radix, pre)
+		gotN, gotErr := UnpackBlock(got, packed, radix, pre)
+		assert.Equal(t, wantN, gotN)
+		assert.Equal(t, wantErr, gotErr)
+		assert.Equal(t, want, got)
+	})
+}
+
+func benchmarkUnpackBlock(b *testing.B, radix int, unpackBlock func(unpacked, packed []byte, radix, pre int) (int, error)) {
+	const words = 64 * 1024
+	dpw := ycd.DigitsPerWord(radix)
+	rnd := rand.New(rand.NewSource(1))
+	packed := make([]byte, words*WordSize)
+	for i := 0; i < words; i++ {
+		w := rnd.Uint64()
+		if limit := wordLimits[radix]; limit != 0 {
+			w %= limit
+		}
+		binary.LittleEndian.PutUint64(packed[i*WordSize:], w)
+	}
+	unpacked := make([]byte, words*dpw)
+	b.SetBytes(int64(len(unpacked)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := unpackBlock(unpacked, packed, radix, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnpackBlock(b *testing.B) {
+	for _, radix := range []int{10, 16, 7} {
+		radix := radix
+		b.Run(fmt.Sprintf("Radix %d", radix), func(b *testing.B) {
+			benchmarkUnpackBlock(b, radix, UnpackBlock)
+		})
+		b.Run(fmt.Sprintf("Radix %d Strconv", radix), func(b *testing.B) {
+			benchmarkUnpackBlock(b, radix, unpackBlockStrconv)
+		})
+	}
+}
@@ -17,21 +17,38 @@ package ycd
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
 )
 
+// These errors match ErrInvalidHeader with errors.Is.
+var (
+	ErrUnsupportedVersion = fmt.Errorf("%w: unsupported file version", ErrInvalidHeader)
+	ErrUnsupportedRadix   = fmt.Errorf("%w: unsupported radix", ErrInvalidHeader)
+	ErrMalformedLine      = fmt.Errorf("%w: malformed line", ErrInvalidHeader)
+	ErrDuplicateKey       = fmt.Errorf("%w: duplicate key", ErrInvalidHeader)
+	ErrHeaderTooLong      = fmt.Errorf("%w: header too long", ErrInvalidHeader)
+)
+
+// MaxHeaderLength is the maximum byte length of a header Parse accepts.
+const MaxHeaderLength = 64 * 1024
+
 type Header struct {
-	// FileVersion is the version of the ycd file.
-	// Currently it's 1.1.0 and this code is tested against the version.
+	// FileVersion is the version of the ycd file, e.g. 1.1.0.
+	// Versions 1.x are supported; this code is tested against 1.1.0.
 	FileVersion string
 
-	// Radis is the radix of the file. 10 or 16.
+	// Radix is the radix of the file, 10 or 16. Parse returns
+	// ErrUnsupportedRadix for other radices.
 	Radix int
 
-	// FirstDigits is always the first several digits of pi?
-	// e.g. 3.14159265358979323846264338327950288419716939937510 for decimal and
-	// 3.243f6a8885a308d313198a2e03707344a4093822299f31d008 for hexadecimal.
+	// FirstDigits is the first digits of the constant including the integer
+	// part, e.g. 3.14159265358979323846264338327950288419716939937510 for pi
+	// in decimal, 3.243f6a8885a308d313198a2e03707344a4093822299f31d008 in
+	// hexadecimal and 2.71828182845904523536028747135266249775724709369995
+	// for e. The packed digits start after the radix point.
 	FirstDigits string
 
 	// TotalDigits is zero if the file has n == BlockSize.
@@ -44,86 +61,149 @@ type Header struct {
 	// BlockID is the position of the current file.
 	BlockID int64
 
+	// Extra holds the values of unknown keys. It's nil if there are none.
+	Extra map[string]string
+
 	// Length is the total byte length of the header in the file.
 	// It is the offset of the empty line after EndHeader.
 	Length int
 }
 
+// SyntaxError is returned by Parse for malformed header lines.
+// It matches ErrInvalidHeader with errors.Is.
+type SyntaxError struct {
+	// Line is the line number, from 1.
+	Line int
+	// Text is the line without the line break.
+	Text string
+	// Err is the cause, e.g. ErrMalformedLine.
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%v at line %d: %q", e.Err, e.Line, e.Text)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SyntaxError) Is(target error) bool {
+	return target == ErrInvalidHeader
+}
+
 func parseInt64(s string) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)
 }
 
+// supportedVersion reports whether v is a 1.x file version.
+func supportedVersion(v string) bool {
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || parts[0] != "1" {
+		return false
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 32); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
 func (h *Header) validate() error {
-	if h.FileVersion != "1.1.0" {
-		return fmt.Errorf("unknown file version: %s", h.FileVersion)
+	if !supportedVersion(h.FileVersion) {
+		return fmt.Errorf("%w: %q", ErrUnsupportedVersion, h.FileVersion)
+	}
+	if DigitsPerWord(h.Radix) == 0 {
+		return fmt.Errorf("%w: %v", ErrUnsupportedRadix, h.Radix)
 	}
-	if h.Radix != 10 && h.Radix != 16 {
-		return fmt.Errorf("unknown radix: %v", h.Radix)
+	if h.BlockSize <= 0 || h.BlockID < 0 || h.TotalDigits < 0 {
+		return fmt.Errorf("%w: block size %d, block ID %d, total digits %d",
+			ErrInvalidHeader, h.BlockSize, h.BlockID, h.TotalDigits)
 	}
 	return nil
 }
 
+// readLine reads a line ending with \n or \r\n and returns it without the
+// line break.
+func readLine(reader *bufio.Reader, length *int) (string, error) {
+	line, err := reader.ReadString('\n')
+	*length += len(line)
+	if *length > MaxHeaderLength {
+		return "", ErrHeaderTooLong
+	}
+	if err == io.EOF {
+		return "", io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// parseHeader parses the header up to the EndHeader line.
+// Lines may end with \r\n as written by y-cruncher or \n.
 func parseHeader(reader *bufio.Reader) (*Header, error) {
 	var h Header
 	length := 0
 
-	line, err := reader.ReadString('\n')
+	line, err := readLine(reader, &length)
 	if err != nil {
 		return nil, err
 	}
-	length += len(line)
 	if strings.TrimSpace(line) != "#Compressed Digit File" {
-		return nil, fmt.Errorf("first line should be '#Compressed Digit File': %s", strings.TrimSpace(line))
+		return nil, &SyntaxError{Line: 1, Text: line, Err: ErrMalformedLine}
 	}
 
-	for {
-		line, err := reader.ReadString('\n')
+	seen := make(map[string]bool)
+	for n := 2; ; n++ {
+		line, err := readLine(reader, &length)
 		if err != nil {
 			return nil, err
 		}
-		length += len(line)
-		// The delimiter is \r\n so it's two runes.
-		if len(line) == 2 {
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
-		tokens := strings.Split(line, ":")
-		key := strings.TrimSpace(tokens[0])
-		if key == "EndHeader" {
+		if strings.TrimSpace(line) == "EndHeader" {
 			break
 		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			return nil, &SyntaxError{Line: n, Text: line, Err: ErrMalformedLine}
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+		if key == "" {
+			return nil, &SyntaxError{Line: n, Text: line, Err: ErrMalformedLine}
+		}
+		if seen[key] {
+			return nil, &SyntaxError{Line: n, Text: line, Err: ErrDuplicateKey}
+		}
+		seen[key] = true
 
-		value := strings.TrimSpace(tokens[1])
+		var perr error
 		switch key {
 		case "FileVersion":
 			h.FileVersion = value
 		case "Base":
-			if i, err := strconv.Atoi(value); err == nil {
-				h.Radix = i
-			} else {
-				return nil, err
-			}
+			h.Radix, perr = strconv.Atoi(value)
 		case "FirstDigits":
 			h.FirstDigits = value
 		case "TotalDigits":
-			if i, err := parseInt64(value); err == nil {
-				h.TotalDigits = i
-			} else {
-				return nil, err
-			}
+			h.TotalDigits, perr = parseInt64(value)
 		case "Blocksize":
-			if i, err := parseInt64(value); err == nil {
-				h.BlockSize = i
-			} else {
-				return nil, err
-			}
+			h.BlockSize, perr = parseInt64(value)
 		case "BlockID":
-			if i, err := parseInt64(value); err == nil {
-				h.BlockID = i
-			} else {
-				return nil, err
-			}
+			h.BlockID, perr = parseInt64(value)
 		default:
-			return nil, fmt.Errorf("unknown header key: %s", key)
+			if h.Extra == nil {
+				h.Extra = make(map[string]string)
+			}
+			h.Extra[key] = value
+		}
+		if perr != nil {
+			return nil, &SyntaxError{Line: n, Text: line, Err: perr}
 		}
 	}
 	if err := h.validate(); err != nil {
@@ -133,3 +213,32 @@ func parseHeader(reader *bufio.Reader) (*Header, error) {
 	h.Length = length
 	return &h, nil
 }
+
+// WriteTo writes the header as y-cruncher does, with \r\n line breaks,
+// followed by the nil character preceding the first digit.
+// Extra keys are written in sorted order before EndHeader.
+// It doesn't update h.Length; see Writer.File.
+func (h *Header) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString("#Compressed Digit File\r\n\r\n")
+	fmt.Fprintf(&b, "FileVersion:\t%s\r\n\r\n", h.FileVersion)
+	fmt.Fprintf(&b, "Base:\t%d\r\n\r\n", h.Radix)
+	fmt.Fprintf(&b, "FirstDigits:\t%s\r\n\r\n", h.FirstDigits)
+	fmt.Fprintf(&b, "TotalDigits:\t%d\r\n\r\n", h.TotalDigits)
+	fmt.Fprintf(&b, "Blocksize:\t%d\r\n", h.BlockSize)
+	fmt.Fprintf(&b, "BlockID:\t%d\r\n\r\n", h.BlockID)
+	if len(h.Extra) > 0 {
+		keys := make([]string, 0, len(h.Extra))
+		for k := range h.Extra {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s:\t%s\r\n", k, h.Extra[k])
+		}
+		b.WriteString("\r\n")
+	}
+	b.WriteString("EndHeader\r\n\r\n\x00")
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
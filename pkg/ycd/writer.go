@@ -0,0 +1,171 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ycd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	ErrInvalidHeader = errors.New("ycd: invalid header")
+	ErrInvalidDigit  = errors.New("ycd: invalid digit")
+	ErrDigitCount    = errors.New("ycd: wrong number of digits")
+	ErrClosed        = errors.New("ycd: writer closed")
+)
+
+// BlockDigits returns the number of digits stored in the block of h.
+// It's BlockSize except for the last block of a result whose TotalDigits
+// isn't a multiple of BlockSize.
+func (h *Header) BlockDigits() int64 {
+	if h.TotalDigits == 0 {
+		return h.BlockSize
+	}
+	n := h.TotalDigits - h.BlockID*h.BlockSize
+	if n > h.BlockSize {
+		return h.BlockSize
+	}
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Writer packs digits into a ycd file.
+// Digits are written as ASCII ("1415..." after the decimal point in block 0)
+// and packed into little-endian words of DigitsPerWord digits. The last word
+// is padded with zeros if the number of digits in the block isn't a multiple
+// of DigitsPerWord.
+type Writer struct {
+	w         io.Writer
+	h         Header
+	dpw       int
+	expected  int64
+	written   int64
+	headerLen int
+	// word holds the digits of the word being filled.
+	word   []byte
+	closed bool
+}
+
+// NewWriter validates h, writes it to w and returns a Writer for the digits
+// of the block. The block must have h.BlockDigits() digits.
+func NewWriter(w io.Writer, h *Header) (*Writer, error) {
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+	if h.BlockDigits() == 0 {
+		return nil, fmt.Errorf("%w: block %d is past total digits %d", ErrInvalidHeader, h.BlockID, h.TotalDigits)
+	}
+	n, err := h.WriteTo(w)
+	if err != nil {
+		return nil, err
+	}
+	dpw := DigitsPerWord(h.Radix)
+	return &Writer{
+		w:         w,
+		h:         *h,
+		dpw:       dpw,
+		expected:  h.BlockDigits(),
+		headerLen: int(n),
+		word:      make([]byte, 0, dpw),
+	}, nil
+}
+
+// Write packs the digits in p.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if w.written+int64(len(p)) > w.expected {
+		return 0, fmt.Errorf("%w: writing %d digits after %d in a block of %d",
+			ErrDigitCount, len(p), w.written, w.expected)
+	}
+	for i, d := range p {
+		if !isDigit(d, w.h.Radix) {
+			return i, fmt.Errorf("%w: %q at %d", ErrInvalidDigit, d, w.written)
+		}
+		w.word = append(w.word, d)
+		w.written++
+		if len(w.word) == w.dpw {
+			if err := w.flush(); err != nil {
+				return i + 1, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// WriteString packs the digits in s.
+func (w *Writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func isDigit(d byte, radix int) bool {
+	switch {
+	case '0' <= d && d <= '9':
+		return int(d-'0') < radix
+	case 'a' <= d && d <= 'z':
+		return int(d-'a')+10 < radix
+	}
+	return false
+}
+
+// flush writes the current word, padded with zeros.
+func (w *Writer) flush() error {
+	for len(w.word) < w.dpw {
+		w.word = append(w.word, '0')
+	}
+	v, err := strconv.ParseUint(string(w.word), w.h.Radix, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidDigit, w.word)
+	}
+	var buf [WordSize]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	w.word = w.word[:0]
+	_, err = w.w.Write(buf[:])
+	return err
+}
+
+// Close writes the last partial word. It returns ErrDigitCount if fewer
+// digits than the block holds were written. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+	if w.written != w.expected {
+		return fmt.Errorf("%w: wrote %d digits in a block of %d", ErrDigitCount, w.written, w.expected)
+	}
+	if len(w.word) > 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+// File returns the YCDFile describing the file written by w as Parse would.
+func (w *Writer) File(name string) *YCDFile {
+	h := w.h
+	// The header is followed by an empty line and the nil character.
+	h.Length = w.headerLen - 3
+	return &YCDFile{
+		Header:           &h,
+		Name:             name,
+		FirstDigitOffset: w.headerLen,
+	}
+}
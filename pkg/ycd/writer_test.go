@@ -0,0 +1,158 @@
+// Copyright 2022 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The writer is tested with the unpacker, which imports ycd.
+package ycd_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/googlecloudplatform/pi-delivery/pkg/unpack"
+	"github.com/googlecloudplatform/pi-delivery/pkg/ycd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	decDigits = "14159265358979323846264338327950288419716939937510"
+	hexDigits = "243f6a8885a308d313198a2e03707344a4093822299f31d008"
+)
+
+const rawHeaderHex = "#Compressed Digit File\r\n\r\n" +
+	"FileVersion:\t1.1.0\r\n\r\n" +
+	"Base:\t16\r\n\r\n" +
+	"FirstDigits:\t3.243f6a8885a308d313198a2e03707344a4093822299f31d008\r\n\r\n" +
+	"TotalDigits:\t0\r\n\r\n" +
+	"Blocksize:\t1000000\r\n" +
+	"BlockID:\t0\r\n\r\n" +
+	"EndHeader\r\n\r\n\x00"
+
+func TestWriter_Header(t *testing.T) {
+	h := &ycd.Header{
+		FileVersion: "1.1.0",
+		Radix:       16,
+		FirstDigits: "3." + hexDigits,
+		BlockSize:   1000000,
+	}
+	var buf bytes.Buffer
+	n, err := h.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(rawHeaderHex)), n)
+	assert.Equal(t, rawHeaderHex, buf.String())
+
+	f, err := ycd.Parse(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 192, f.Header.Length)
+	assert.Equal(t, 195, f.FirstDigitOffset)
+	h.Length = 192
+	assert.Equal(t, h, f.Header)
+}
+
+func TestWriter_BlockDigits(t *testing.T) {
+	h := &ycd.Header{BlockSize: 1000000, BlockID: 50, TotalDigits: 50000001}
+	assert.Equal(t, int64(1), h.BlockDigits())
+	h.BlockID = 49
+	assert.Equal(t, int64(1000000), h.BlockDigits())
+	h.BlockID = 51
+	assert.Equal(t, int64(0), h.BlockDigits())
+	h.TotalDigits = 0
+	assert.Equal(t, int64(1000000), h.BlockDigits())
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		radix  int
+		digits string
+	}{
+		{10, decDigits},
+		{10, decDigits[:38]},
+		{10, decDigits[:1]},
+		{16, hexDigits},
+		{16, hexDigits[:32]},
+	} {
+		h := &ycd.Header{
+			FileVersion: "1.1.0",
+			Radix:       tc.radix,
+			FirstDigits: "3." + tc.digits,
+			BlockSize:   int64(len(tc.digits)),
+			BlockID:     0,
+		}
+		var buf bytes.Buffer
+		w, err := ycd.NewWriter(&buf, h)
+		require.NoError(t, err)
+		// Split writes across word boundaries.
+		for _, part := range []string{tc.digits[:len(tc.digits)/3], tc.digits[len(tc.digits)/3:]} {
+			n, err := w.WriteString(part)
+			require.NoError(t, err)
+			assert.Equal(t, len(part), n)
+		}
+		require.NoError(t, w.Close())
+
+		f := w.File("test.ycd")
+		parsed, err := ycd.Parse(bytes.NewReader(buf.Bytes()))
+		require.NoError(t, err)
+		parsed.Name = "test.ycd"
+		assert.Equal(t, parsed, f)
+
+		dpw := ycd.DigitsPerWord(tc.radix)
+		words := (len(tc.digits) + dpw - 1) / dpw
+		packed := buf.Bytes()[f.FirstDigitOffset:]
+		require.Len(t, packed, words*ycd.WordSize)
+		assert.Equal(t, f.BlockByteLength(), int64(len(packed)))
+
+		unpacked := make([]byte, words*dpw)
+		n, err := unpack.UnpackBlock(unpacked, packed, tc.radix, 0)
+		require.NoError(t, err)
+		assert.Equal(t, words*dpw, n)
+		// The last word is padded with zeros.
+		assert.Equal(t, tc.digits+strings.Repeat("0", words*dpw-len(tc.digits)), string(unpacked))
+	}
+}
+
+func TestWriter_Errors(t *testing.T) {
+	h := &ycd.Header{FileVersion: "1.1.0", Radix: 10, BlockSize: 20}
+	for _, bad := range []ycd.Header{
+		{FileVersion: "2.0.0", Radix: 10, BlockSize: 20},
+		{FileVersion: "1.1.0", Radix: 8, BlockSize: 20},
+		{FileVersion: "1.1.0", Radix: 37, BlockSize: 20},
+		{FileVersion: "1.1.0", Radix: 10},
+		{FileVersion: "1.1.0", Radix: 10, BlockSize: 20, BlockID: 2, TotalDigits: 30},
+	} {
+		bad := bad
+		_, err := ycd.NewWriter(&bytes.Buffer{}, &bad)
+		assert.ErrorIs(t, err, ycd.ErrInvalidHeader)
+	}
+
+	w, err := ycd.NewWriter(&bytes.Buffer{}, h)
+	require.NoError(t, err)
+	n, err := w.WriteString("123a")
+	assert.ErrorIs(t, err, ycd.ErrInvalidDigit)
+	assert.Equal(t, 3, n)
+	_, err = w.WriteString(strings.Repeat("1", 18))
+	assert.ErrorIs(t, err, ycd.ErrDigitCount)
+	_, err = w.WriteString("4")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, w.Close(), ycd.ErrDigitCount)
+	assert.ErrorIs(t, w.Close(), ycd.ErrClosed)
+	_, err = w.WriteString("1")
+	assert.ErrorIs(t, err, ycd.ErrClosed)
+
+	h = &ycd.Header{FileVersion: "1.1.0", Radix: 16, BlockSize: 20}
+	w, err = ycd.NewWriter(&bytes.Buffer{}, h)
+	require.NoError(t, err)
+	_, err = w.WriteString("ABC")
+	assert.ErrorIs(t, err, ycd.ErrInvalidDigit)
+}
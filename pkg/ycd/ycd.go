@@ -28,15 +28,23 @@ type YCDFile struct {
 // WordSize is the size of a word (64 bits / 8 bytes).
 const WordSize = 8
 
-// DigitsPerWord returns the number of digits per word (64 bits).
+// Radices are the radices of ycd files, decimal and hexadecimal.
+var Radices = []int{10, 16}
+
+// MaxRadix is the largest of Radices.
+const MaxRadix = 16
+
+// DigitsPerWord returns the number of digits per word (64 bits),
+// 19 for radix 10 and 16 for radix 16.
+// It returns 0 for other radices, which ycd files don't use.
 func DigitsPerWord(radix int) int {
-	if radix == 10 {
+	switch radix {
+	case 10:
 		return 19
-	} else if radix == 16 {
+	case 16:
 		return 16
-	} else {
-		panic("unknown radix")
 	}
+	return 0
 }
 
 // Parse parses the header of a ycd file and returns the field values.
@@ -15,6 +15,8 @@
 package ycd
 
 import (
+	"io"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -100,4 +102,114 @@ func TestYCD_ParseDec(t *testing.T) {
 func TestYCD_DigitsPerWord(t *testing.T) {
 	assert.Equal(t, 19, DigitsPerWord(10))
 	assert.Equal(t, 16, DigitsPerWord(16))
+	assert.Zero(t, DigitsPerWord(0))
+	assert.Zero(t, DigitsPerWord(2))
+	assert.Zero(t, DigitsPerWord(8))
+	assert.Zero(t, DigitsPerWord(36))
+	assert.Zero(t, DigitsPerWord(37))
+	assert.Zero(t, DigitsPerWord(-10))
+}
+
+func TestYCD_ParseLF(t *testing.T) {
+	ycd, err := Parse(strings.NewReader(rawTestDataDec + "\x00"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 10, ycd.Header.Radix)
+		assert.Equal(t, int64(50000001), ycd.Header.TotalDigits)
+		assert.Equal(t, int64(50), ycd.Header.BlockID)
+		assert.Equal(t, 186, ycd.Header.Length)
+		assert.Equal(t, 188, ycd.FirstDigitOffset)
+		assert.Nil(t, ycd.Header.Extra)
+	}
+}
+
+func TestYCD_ParseExtra(t *testing.T) {
+	raw := strings.Replace(rawTestDataHex, "EndHeader", "Constant:\tPi\nAlgorithm:  Chudnovsky (1988)\n\nEndHeader", 1)
+	raw = strings.ReplaceAll(raw, "1.1.0", "1.2.3")
+	ycd, err := Parse(strings.NewReader(raw + "\x00"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1.2.3", ycd.Header.FileVersion)
+		assert.Equal(t, map[string]string{
+			"Constant":  "Pi",
+			"Algorithm": "Chudnovsky (1988)",
+		}, ycd.Header.Extra)
+	}
+}
+
+func TestYCD_ParseErrors(t *testing.T) {
+	replace := func(old, new string) string {
+		return strings.Replace(rawTestDataDec, old, new, 1) + "\x00"
+	}
+	testCases := []struct {
+		name string
+		raw  string
+		err  error
+		line int
+	}{
+		{"Empty", "", io.ErrUnexpectedEOF, 0},
+		{"No EndHeader", replace("EndHeader", ""), io.ErrUnexpectedEOF, 0},
+		{"No nil", strings.TrimSuffix(replace("", ""), "\x00"), io.EOF, 0},
+		{"First line", replace("#Compressed", "#Uncompressed"), ErrMalformedLine, 1},
+		{"No colon", replace("Base:", "Base"), ErrMalformedLine, 5},
+		{"No key", replace("Base:", ":"), ErrMalformedLine, 5},
+		{"Duplicate key", replace("BlockID:", "Blocksize:"), ErrDuplicateKey, 12},
+		{"Bad number", replace("50000001", "5e7"), strconv.ErrSyntax, 9},
+		{"Overflow", replace("50000001", "99999999999999999999"), strconv.ErrRange, 9},
+		{"Version 2", replace("1.1.0", "2.0.0"), ErrUnsupportedVersion, 0},
+		{"Bad version", replace("1.1.0", "1.x"), ErrUnsupportedVersion, 0},
+		{"No version", replace("FileVersion:\t1.1.0", ""), ErrUnsupportedVersion, 0},
+		{"Radix 8", replace("Base:\t10", "Base:\t8"), ErrUnsupportedRadix, 0},
+		{"Radix 37", replace("Base:\t10", "Base:\t37"), ErrUnsupportedRadix, 0},
+		{"No block size", replace("Blocksize:\t1000000", ""), ErrInvalidHeader, 0},
+		{"Negative block ID", replace("BlockID:\t50", "BlockID:\t-1"), ErrInvalidHeader, 0},
+		{"Too long", replace("EndHeader", strings.Repeat("\n", MaxHeaderLength)), ErrHeaderTooLong, 0},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.raw))
+			assert.ErrorIs(t, err, tc.err)
+			var serr *SyntaxError
+			if tc.line > 0 && assert.ErrorAs(t, err, &serr) {
+				assert.Equal(t, tc.line, serr.Line)
+				assert.ErrorIs(t, err, ErrInvalidHeader)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, raw := range []string{rawTestDataHex, rawTestDataDec} {
+		f.Add(raw + "\x00")
+		f.Add(strings.ReplaceAll(raw, "\n", "\r\n") + "\x00")
+	}
+	f.Add("#Compressed Digit File\nFileVersion: 1.0\nBase: 2\nBlocksize: 1\nKey: value\nEndHeader\n\x00")
+	f.Fuzz(func(t *testing.T, raw string) {
+		y, err := Parse(strings.NewReader(raw))
+		if err != nil {
+			return
+		}
+		h := y.Header
+		if err := h.validate(); err != nil {
+			t.Fatalf("Parse returned an invalid header: %v", err)
+		}
+		if y.FirstDigitOffset <= h.Length || y.FirstDigitOffset > len(raw) || raw[y.FirstDigitOffset-1] != 0 {
+			t.Fatalf("bad offsets: length %d, first digit %d", h.Length, y.FirstDigitOffset)
+		}
+		if y.BlockByteLength() <= 0 {
+			t.Fatalf("bad block byte length: %d", y.BlockByteLength())
+		}
+
+		// The header is written back in the canonical form.
+		var b strings.Builder
+		if _, err := h.WriteTo(&b); err != nil {
+			t.Fatal(err)
+		}
+		y2, err := Parse(strings.NewReader(b.String()))
+		if err != nil {
+			t.Fatalf("failed to parse the written header: %v\n%q", err, b.String())
+		}
+		h.Length = y2.Header.Length
+		assert.Equal(t, h, y2.Header)
+		assert.Equal(t, b.Len(), y2.FirstDigitOffset)
+	})
 }